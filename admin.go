@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+)
+
+// CollectionSpec ListCollections返回的集合信息
+type CollectionSpec struct {
+	Name     string
+	Type     string // collection或view
+	ReadOnly bool
+	Info     *mgo.CollectionInfo // 创建集合时的选项, 只包含capped, validator, collation等, 不含DisableIdIndex及ForceIdIndex
+}
+
+// CollStats collStats的结果, 大小的单位为字节
+type CollStats struct {
+	Ns             string           `bson:"ns"`
+	Count          int              `bson:"count"`
+	Size           int64            `bson:"size"` // 文档未压缩的总大小
+	AvgObjSize     int64            `bson:"avgObjSize"`
+	StorageSize    int64            `bson:"storageSize"` // 分配的存储空间
+	Nindexes       int              `bson:"nindexes"`
+	TotalIndexSize int64            `bson:"totalIndexSize"`
+	IndexSizes     map[string]int64 `bson:"indexSizes"`
+	Capped         bool             `bson:"capped"`
+	Max            int64            `bson:"max"`     // capped集合的最大文档数
+	MaxSize        int64            `bson:"maxSize"` // capped集合的最大字节数
+}
+
+// DatabaseSpec ListDatabases返回的数据库信息
+type DatabaseSpec struct {
+	Name       string `bson:"name"`
+	SizeOnDisk int64  `bson:"sizeOnDisk"`
+	Empty      bool   `bson:"empty"`
+}
+
+// DBStats dbStats的结果, 大小的单位为字节
+type DBStats struct {
+	DB          string  `bson:"db"`
+	Collections int     `bson:"collections"`
+	Views       int     `bson:"views"`
+	Objects     int64   `bson:"objects"`
+	AvgObjSize  float64 `bson:"avgObjSize"`
+	DataSize    int64   `bson:"dataSize"`
+	StorageSize int64   `bson:"storageSize"`
+	Indexes     int     `bson:"indexes"`
+	IndexSize   int64   `bson:"indexSize"`
+}
+
+// collectionDoc listCollections返回的文档
+type collectionDoc struct {
+	Name    string `bson:"name"`
+	Type    string `bson:"type"`
+	Options struct {
+		Capped           bool           `bson:"capped"`
+		Size             int            `bson:"size"`
+		Max              int            `bson:"max"`
+		Validator        interface{}    `bson:"validator"`
+		ValidationLevel  string         `bson:"validationLevel"`
+		ValidationAction string         `bson:"validationAction"`
+		StorageEngine    interface{}    `bson:"storageEngine"`
+		Collation        *mgo.Collation `bson:"collation"`
+	} `bson:"options"`
+	Info struct {
+		ReadOnly bool `bson:"readOnly"`
+	} `bson:"info"`
+}
+
+func (doc *collectionDoc) spec() CollectionSpec {
+	o := doc.Options
+	return CollectionSpec{
+		Name:     doc.Name,
+		Type:     doc.Type,
+		ReadOnly: doc.Info.ReadOnly,
+		Info: &mgo.CollectionInfo{
+			Capped:           o.Capped,
+			MaxBytes:         o.Size,
+			MaxDocs:          o.Max,
+			Validator:        o.Validator,
+			ValidationLevel:  o.ValidationLevel,
+			ValidationAction: o.ValidationAction,
+			StorageEngine:    o.StorageEngine,
+			Collation:        o.Collation,
+		},
+	}
+}
+
+// nsNotFound 集合或数据库不存在, 较早版本的drop在此时报错
+func nsNotFound(err error) bool {
+	if qe, ok := err.(*mgo.QueryError); ok {
+		return qe.Code == 26 || qe.Message == "ns not found"
+	}
+	return err != nil && err.Error() == "ns not found"
+}
@@ -0,0 +1,101 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryAdmin(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	info := &mgo.CollectionInfo{Capped: true, MaxBytes: 1 << 20, MaxDocs: 100, Validator: bson.M{"uid": bson.M{"$exists": true}}}
+	if err := m.CreateCollection("event", info); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreateCollection("event", nil); err == nil || err.(*mgo.QueryError).Code != 48 {
+		t.Errorf("CreateCollection existing: %v", err)
+	}
+	if err := m.CreateCollection("log", &mgo.CollectionInfo{Capped: true}); err == nil {
+		t.Error("CreateCollection capped without MaxBytes: expected error")
+	}
+
+	specs, err := m.ListCollections()
+	if err != nil || len(specs) != 2 || specs[0].Name != "event" || specs[1].Name != "user" || !reflect.DeepEqual(specs[0].Info, info) {
+		t.Errorf("ListCollections: %+v %v", specs, err)
+	}
+
+	stats, err := m.CollectionStats("event")
+	if err != nil || stats.Ns != "test.event" || stats.Count != 0 || !stats.Capped || stats.Max != 100 || stats.MaxSize != 1<<20 {
+		t.Errorf("CollectionStats event: %+v %v", stats, err)
+	}
+	m.EnsureIndexKey("user", "name")
+	stats, err = m.CollectionStats("user")
+	if err != nil || stats.Count != 4 || stats.Size <= 0 || stats.AvgObjSize != stats.Size/4 || stats.Nindexes != 2 || len(stats.IndexSizes) != 2 {
+		t.Errorf("CollectionStats user: %+v %v", stats, err)
+	}
+
+	if err = m.RenameCollection("none", "x", false); err == nil {
+		t.Error("RenameCollection missing source: expected error")
+	}
+	if err = m.RenameCollection("event", "user", false); err == nil {
+		t.Error("RenameCollection existing target: expected error")
+	}
+	if err = m.RenameCollection("event", "event2", false); err != nil {
+		t.Error(err)
+	}
+	if err = m.DropCollection("event2"); err != nil {
+		t.Error(err)
+	}
+	if err = m.DropCollection("event2"); err != nil {
+		t.Errorf("DropCollection missing: %v", err)
+	}
+
+	if err = m.DBInsert("other", "a", bson.M{"_id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	dbs, err := m.ListDatabases()
+	if err != nil || len(dbs) != 2 || dbs[0].Name != "other" || dbs[1].Name != "test" || dbs[1].Empty || dbs[1].SizeOnDisk != stats.Size {
+		t.Errorf("ListDatabases: %+v %v", dbs, err)
+	}
+	ds, err := m.DatabaseStats()
+	if err != nil || ds.DB != "test" || ds.Collections != 1 || ds.Objects != 4 || ds.DataSize != stats.Size || ds.Indexes != 2 {
+		t.Errorf("DatabaseStats: %+v %v", ds, err)
+	}
+	if err = m.DBDropDatabase("other"); err != nil {
+		t.Error(err)
+	}
+	if dbs, _ = m.ListDatabases(); len(dbs) != 1 {
+		t.Errorf("ListDatabases after DropDatabase: %+v", dbs)
+	}
+}
+
+func TestAdminDecode(t *testing.T) {
+	data, _ := bson.Marshal(bson.M{
+		"name": "event",
+		"type": "collection",
+		"options": bson.M{
+			"capped": true, "size": 1024, "max": 10,
+			"validationLevel": "moderate",
+			"collation":       bson.M{"locale": "en", "strength": 2},
+		},
+		"info": bson.M{"readOnly": true, "uuid": bson.Binary{Kind: 4}},
+	})
+	var doc collectionDoc
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	spec := doc.spec()
+	if spec.Name != "event" || !spec.ReadOnly || !spec.Info.Capped || spec.Info.MaxBytes != 1024 || spec.Info.MaxDocs != 10 ||
+		spec.Info.ValidationLevel != "moderate" || spec.Info.Collation == nil || spec.Info.Collation.Strength != 2 {
+		t.Errorf("collectionDoc: %+v %+v", spec, spec.Info)
+	}
+
+	// collStats中的数值可能为int32, int64或double
+	data, _ = bson.Marshal(bson.M{"ns": "test.user", "count": int32(4), "size": int64(400), "avgObjSize": 100.0, "indexSizes": bson.M{"_id_": int32(4096)}, "ok": 1.0})
+	var stats CollStats
+	if err := bson.Unmarshal(data, &stats); err != nil || stats.Count != 4 || stats.Size != 400 || stats.AvgObjSize != 100 || stats.IndexSizes["_id_"] != 4096 {
+		t.Errorf("CollStats: %+v %v", stats, err)
+	}
+}
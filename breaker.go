@@ -0,0 +1,177 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("mongo circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerPolicy 熔断策略. 只有网络及主节点切换错误计为失败, 业务错误(如重复键)及ctx的取消或超时不计
+type BreakerPolicy struct {
+	FailureRate    float64                                    // 窗口内失败比例达到该值时断开, 0~1, 默认0.5
+	MinRequests    int                                        // 窗口内请求数达到该值才计算失败率, 默认10
+	Window         time.Duration                              // 统计窗口, 默认10s
+	OpenDuration   time.Duration                              // 断开持续时间, 之后进入半开, 默认30s
+	HalfOpenProbes int                                        // 半开时放行的探测请求数, 全部成功则闭合, 默认1
+	OnStateChange  func(client string, from, to BreakerState) // 状态变化回调, 默认使用BreakerStateChange
+}
+
+// BreakerStateChange 未设置BreakerPolicy.OnStateChange时的状态变化回调, 可在conf.yml配置的实例初始化后设置
+var BreakerStateChange func(client string, from, to BreakerState)
+
+type breaker struct {
+	sync.Mutex
+	client string
+	policy BreakerPolicy
+
+	state    BreakerState
+	gen      uint64    // 每次状态变化加1, 旧状态下放行的请求结果不再计入
+	start    time.Time // 窗口开始时间或断开时间
+	requests int
+	failures int
+	probes   int // 半开时已放行的探测数
+	passed   int // 半开时成功的探测数
+}
+
+func newBreaker(client string, p *BreakerPolicy) *breaker {
+	b := &breaker{client: client, policy: *p, start: time.Now()}
+	if b.policy.FailureRate <= 0 {
+		b.policy.FailureRate = 0.5
+	}
+	if b.policy.MinRequests <= 0 {
+		b.policy.MinRequests = 10
+	}
+	if b.policy.Window <= 0 {
+		b.policy.Window = 10 * time.Second
+	}
+	if b.policy.OpenDuration <= 0 {
+		b.policy.OpenDuration = 30 * time.Second
+	}
+	if b.policy.HalfOpenProbes <= 0 {
+		b.policy.HalfOpenProbes = 1
+	}
+	return b
+}
+
+func (b *breaker) interceptor() Interceptor {
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		gen, ok := b.allow()
+		if !ok {
+			return ErrCircuitOpen
+		}
+		err := next(ctx, op)
+		// 调用方自己的取消或超时不代表集群故障, 不计入统计
+		if err != nil && (err == context.Canceled || err == context.DeadlineExceeded || ctx.Err() != nil) {
+			b.cancel(gen)
+			return err
+		}
+		b.done(gen, err != nil && retryClass(err, []string{RetryNetwork, RetryNotMaster}))
+		return err
+	}
+}
+
+// allow 是否放行, 同时返回放行时的状态代数
+func (b *breaker) allow() (uint64, bool) {
+	b.Lock()
+	from, ok := b.state, true
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.start) < b.policy.OpenDuration {
+			ok = false
+			break
+		}
+		b.state, b.probes, b.passed = BreakerHalfOpen, 0, 0
+		b.gen++
+		fallthrough
+	case BreakerHalfOpen:
+		if ok = b.probes < b.policy.HalfOpenProbes; ok {
+			b.probes++
+		}
+	default:
+		if now.Sub(b.start) >= b.policy.Window {
+			b.start, b.requests, b.failures = now, 0, 0
+		}
+	}
+	gen, to := b.gen, b.state
+	b.Unlock()
+	b.notify(from, to)
+	return gen, ok
+}
+
+// done 记录gen代放行的请求结果, 状态已变化时忽略
+func (b *breaker) done(gen uint64, failed bool) {
+	b.Lock()
+	from := b.state
+	if gen != b.gen {
+		b.Unlock()
+		return
+	}
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			b.state, b.start = BreakerOpen, time.Now()
+			b.gen++
+		} else if b.passed++; b.passed >= b.policy.HalfOpenProbes {
+			b.state, b.start, b.requests, b.failures = BreakerClosed, time.Now(), 0, 0
+			b.gen++
+		}
+	case BreakerClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.policy.MinRequests && float64(b.failures) >= b.policy.FailureRate*float64(b.requests) {
+			b.state, b.start = BreakerOpen, time.Now()
+			b.gen++
+		}
+	}
+	to := b.state
+	b.Unlock()
+	b.notify(from, to)
+}
+
+// cancel 请求被调用方取消, 不计结果; 半开时归还探测名额
+func (b *breaker) cancel(gen uint64) {
+	b.Lock()
+	if gen == b.gen && b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	b.Unlock()
+}
+
+func (b *breaker) notify(from, to BreakerState) {
+	if from == to {
+		return
+	}
+	f := b.policy.OnStateChange
+	if f == nil {
+		f = BreakerStateChange
+	}
+	if f != nil {
+		f(b.client, from, to)
+	}
+}
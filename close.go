@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("mongo client is closed")
+
+// lifecycle 跟踪进行中的调用(包括未Close的迭代器), 关闭后拒绝新调用
+type lifecycle struct {
+	sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+}
+
+func (l *lifecycle) acquire() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.inflight++
+	return nil
+}
+
+func (l *lifecycle) release() {
+	l.Lock()
+	defer l.Unlock()
+	if l.inflight--; l.closed && l.inflight == 0 {
+		close(l.drained)
+	}
+}
+
+// shutdown 拒绝新调用, 返回的chan在进行中的调用全部结束后关闭
+func (l *lifecycle) shutdown() <-chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+	if !l.closed {
+		l.closed = true
+		l.drained = make(chan struct{})
+		if l.inflight == 0 {
+			close(l.drained)
+		}
+	}
+	return l.drained
+}
+
+/*
+CloseAll 清空注册表(Default及Clients), 关闭其中的所有实例:
+1. 立即拒绝新调用, 返回ErrClosed
+2. 等待进行中的调用结束后关闭根会话, timeout<=0时一直等待
+超时返回错误, 未结束的实例在调用结束后继续关闭
+*/
+func CloseAll(timeout time.Duration) error {
+	names := defaultRegistry.reset()
+
+	var mu sync.Mutex
+	pending := make(map[Mongo]bool, len(names))
+	for m := range names {
+		pending[m] = true
+	}
+	var wg sync.WaitGroup
+	for m := range names {
+		wg.Add(1)
+		go func(m Mongo) {
+			defer wg.Done()
+			m.Close()
+			mu.Lock()
+			delete(pending, m)
+			mu.Unlock()
+		}(m)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		select {
+		case <-done:
+		case <-t.C:
+			mu.Lock()
+			var keys []string
+			for m := range pending {
+				sort.Strings(names[m])
+				keys = append(keys, strings.Join(names[m], ","))
+			}
+			mu.Unlock()
+			sort.Strings(keys)
+			err = errors.New("mongo close timeout, still draining: " + strings.Join(keys, ";"))
+		}
+		t.Stop()
+	} else {
+		<-done
+	}
+
+	return err
+}
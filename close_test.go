@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCloseAll(t *testing.T) {
+	saved := defaultRegistry
+	defer func() {
+		defaultRegistry = saved
+		Default, Clients = saved.GetDefault(), saved.snapshot()
+	}()
+	defaultRegistry = newDefaultRegistry()
+
+	if err := Setup("close_a,close_b", &Config{Database: "test", Memory: true}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Setup("close_c", &Config{Database: "test", Memory: true}, false); err != nil {
+		t.Fatal(err)
+	}
+	m := Get("close_a").(*memMongo)
+	m.life.acquire() // 模拟进行中的调用
+
+	err := CloseAll(10 * time.Millisecond)
+	if err == nil || err.Error() != "mongo close timeout, still draining: close_a,close_b" {
+		t.Errorf("CloseAll: %v", err)
+	}
+	if Default != nil || len(Clients) != 0 {
+		t.Errorf("CloseAll: Default=%v Clients=%v", Default, Clients)
+	}
+	if _, err = m.Count("user"); err != ErrClosed {
+		t.Errorf("Count after close: %v", err)
+	}
+	if it := m.FindIter("user", nil, 0); it.Close() != ErrClosed {
+		t.Error("FindIter after close: expected ErrClosed")
+	}
+	if err = m.Ping(context.Background()); err != ErrClosed {
+		t.Errorf("Ping after close: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Close returned before in-flight call finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.life.release()
+	<-done
+}
@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// envStringKeys 环境变量的值按原样作为字符串, 其余按YAML解析(如"30s", 16, true, {"W":1})
+var envStringKeys = map[string]bool{
+	"uri": true, "uriFile": true, "address": true, "database": true, "username": true, "password": true, "passwordFile": true,
+	"source": true, "replicaSet": true, "mode": true, "redactFields": true,
+}
+
+/*
+envName 环境变量名: MONGO_<KEY>_<FIELD>, 均转为大写, key中的非字母数字字符转为下划线, field的驼峰转为下划线分隔.
+例如key为test-db时maxPoolSize对应MONGO_TEST_DB_MAX_POOL_SIZE
+*/
+func envName(key string, field string) string {
+	var b strings.Builder
+	b.WriteString("MONGO_")
+	for _, r := range strings.TrimSpace(key) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	b.WriteByte('_')
+	rs := []rune(field)
+	for i, r := range rs {
+		// 驼峰边界: 小写或数字后的大写, 或连续大写后接小写(如TimeMS不拆分MS)
+		if i > 0 && unicode.IsUpper(r) && (!unicode.IsUpper(rs[i-1]) || i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// overrideEnv 返回以环境变量覆盖后的配置副本. key有多个时依次查找, 先找到的生效
+func (p *confParser) overrideEnv(key string, config interface{}) interface{} {
+	ret := make(map[interface{}]interface{})
+	switch config := config.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range config {
+			ret[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range config {
+			ret[k] = v
+		}
+	default:
+		return config
+	}
+	for _, field := range confKeys {
+		if field == "key" {
+			continue
+		}
+		for _, k := range strings.Split(key, ",") {
+			name := envName(k, field)
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if envStringKeys[field] {
+				ret[field] = val
+			} else {
+				var v interface{}
+				if err := yaml.Unmarshal([]byte(val), &v); err != nil {
+					p.addf("invalid %v: %v", name, err)
+					break
+				}
+				ret[field] = v
+			}
+			break
+		}
+	}
+	return ret
+}
+
+// readSecret 读取secret文件, 去除末尾的换行
+func readSecret(path string) (string, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bs), "\r\n"), nil
+}
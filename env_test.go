@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	for _, c := range [][3]string{
+		{"test", "password", "MONGO_TEST_PASSWORD"},
+		{"test-db", "maxPoolSize", "MONGO_TEST_DB_MAX_POOL_SIZE"},
+		{"test", "maxPoolWaitTimeMS", "MONGO_TEST_MAX_POOL_WAIT_TIME_MS"},
+		{" a ", "passwordFile", "MONGO_A_PASSWORD_FILE"},
+	} {
+		if name := envName(c[0], c[1]); name != c[2] {
+			t.Errorf("envName(%q, %q) = %v, expected %v", c[0], c[1], name, c[2])
+		}
+	}
+}
+
+func TestEnvOverride(t *testing.T) {
+	env := map[string]string{
+		"MONGO_ENV_B_PASSWORD":      "0123",
+		"MONGO_ENV_B_MAX_POOL_SIZE": "64",
+		"MONGO_ENV_B_READ_TIMEOUT":  "5s",
+		"MONGO_ENV_B_SAFE":          `{"w": 2}`,
+		"MONGO_ENV_B_STRICT":        "true",
+		"MONGO_ENV_B_RETRY":         "[",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	_, opt, _, err := getConfig(map[interface{}]interface{}{"key": "env_a,env_b", "address": "127.0.0.1", "database": "test", "password": "inline", "maxPoolSize": 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Password != "0123" || opt.MaxPoolSize != 64 || opt.ReadTimeout != 5*time.Second || opt.Safe.W != 2 || !opt.Strict || opt.Database != "test" {
+		t.Errorf("env override: %+v %+v", opt, opt.Safe)
+	}
+	if err = opt.Validate(); err == nil {
+		t.Error("invalid MONGO_ENV_B_RETRY: expected error")
+	}
+}
+
+func TestReloadSecretFile(t *testing.T) {
+	saved, savedLoaded := defaultRegistry, loaded
+	defer func() {
+		defaultRegistry, loaded = saved, savedLoaded
+		Default, Clients = saved.GetDefault(), saved.snapshot()
+	}()
+	defaultRegistry, loaded = newDefaultRegistry(), &confClients{entries: make(map[string]*confEntry)}
+
+	dir, err := ioutil.TempDir("", "mongo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path, secret := filepath.Join(dir, "conf.yml"), filepath.Join(dir, "password")
+	yml := "mongo:\n  - {key: secret, database: test, memory: true, passwordFile: " + secret + "}\n"
+	if err = ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ReloadFile(path); err == nil {
+		t.Error("missing passwordFile: expected error")
+	}
+
+	ioutil.WriteFile(secret, []byte("first\n"), 0600)
+	if err = ReloadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	m := Get("secret")
+	if m == nil || m.(*memMongo).Password != "first" {
+		t.Fatalf("passwordFile: %v", m)
+	}
+	// 文件内容变化后重新加载时重建实例
+	ioutil.WriteFile(secret, []byte("second\n"), 0600)
+	if err = ReloadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := Get("secret"); n == m || n.(*memMongo).Password != "second" {
+		t.Errorf("reload passwordFile: %v", n)
+	}
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo/bson"
+	"strings"
+)
+
+// Plan 查询计划摘要, 由WithExplain填充
+type Plan struct {
+	Stage        string   // 胜出计划的访问stage, 如IXSCAN, COLLSCAN, IDHACK
+	Stages       []string // 胜出计划从根到叶的全部stage, 如[LIMIT FETCH IXSCAN]
+	Index        string   // 使用的索引名, 多个时以逗号分隔, 未使用索引为空
+	DocsExamined int
+	KeysExamined int
+	Returned     int
+	InMemorySort bool   // 包含SORT stage, 即未能使用索引排序
+	Raw          bson.M // explain的原始输出, 内存实现为nil
+}
+
+// HasStage 胜出计划是否包含stage
+func (p *Plan) HasStage(stage string) bool {
+	for _, s := range p.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// CollScan 是否全表扫描
+func (p *Plan) CollScan() bool {
+	return p.HasStage("COLLSCAN")
+}
+
+/*
+parsePlan 从explain输出中提取摘要, 兼容:
+1. find的explain: 顶层queryPlanner及executionStats
+2. aggregate的explain: 顶层(管道整体下推)或stages[0].$cursor中的queryPlanner及executionStats, 其余stage中的$sort视为内存排序
+3. 分片集群: winningPlan.shards[].winningPlan
+4. 5.0以上的SBE: winningPlan.queryPlan
+*/
+func parsePlan(raw bson.M) *Plan {
+	p := &Plan{Raw: raw}
+	planner, stats := asDoc(raw["queryPlanner"]), asDoc(raw["executionStats"])
+	if stages, ok := raw["stages"].([]interface{}); ok {
+		for i, s := range stages {
+			stage := asDoc(s)
+			if cursor := asDoc(stage["$cursor"]); i == 0 && len(cursor) > 0 {
+				planner, stats = asDoc(cursor["queryPlanner"]), asDoc(cursor["executionStats"])
+			} else if _, ok := stage["$sort"]; ok {
+				p.InMemorySort = true
+			}
+		}
+	}
+
+	var indexes []string
+	var walk func(node bson.M)
+	walk = func(node bson.M) {
+		if len(node) == 0 {
+			return
+		}
+		if qp := asDoc(node["queryPlan"]); len(qp) > 0 {
+			walk(qp)
+			return
+		}
+		if stage, ok := node["stage"].(string); ok {
+			p.Stages = append(p.Stages, stage)
+			if stage == "SORT" {
+				p.InMemorySort = true
+			}
+		}
+		if name, ok := node["indexName"].(string); ok && name != "" && !containsString(indexes, name) {
+			indexes = append(indexes, name)
+		}
+		walk(asDoc(node["inputStage"]))
+		if children, ok := node["inputStages"].([]interface{}); ok {
+			for _, child := range children {
+				walk(asDoc(child))
+			}
+		}
+		if shards, ok := node["shards"].([]interface{}); ok {
+			for _, shard := range shards {
+				walk(asDoc(asDoc(shard)["winningPlan"]))
+			}
+		}
+	}
+	walk(asDoc(planner["winningPlan"]))
+
+	// 访问stage为第一个叶子, 即没有下级的stage
+	for _, s := range p.Stages {
+		switch s {
+		case "COLLSCAN", "IXSCAN", "IDHACK", "COUNT_SCAN", "DISTINCT_SCAN", "TEXT", "GEO_NEAR_2D", "GEO_NEAR_2DSPHERE", "EOF":
+			p.Stage = s
+		}
+		if p.Stage != "" {
+			break
+		}
+	}
+	if p.Stage == "" && len(p.Stages) > 0 {
+		p.Stage = p.Stages[len(p.Stages)-1]
+	}
+	p.Index = strings.Join(indexes, ",")
+	p.Returned = int(toFloat(stats["nReturned"]))
+	p.KeysExamined = int(toFloat(stats["totalKeysExamined"]))
+	p.DocsExamined = int(toFloat(stats["totalDocsExamined"]))
+	return p
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
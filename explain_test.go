@@ -0,0 +1,158 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"testing"
+)
+
+func TestParsePlan(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  bson.M
+		want Plan
+	}{
+		{
+			name: "find",
+			raw: bson.M{
+				"queryPlanner": bson.M{"winningPlan": bson.M{
+					"stage": "LIMIT",
+					"inputStage": bson.M{"stage": "FETCH",
+						"inputStage": bson.M{"stage": "IXSCAN", "indexName": "uid_1_ctime_-1"}},
+				}},
+				"executionStats": bson.M{"nReturned": 10, "totalKeysExamined": 10, "totalDocsExamined": int64(10)},
+			},
+			want: Plan{Stage: "IXSCAN", Stages: []string{"LIMIT", "FETCH", "IXSCAN"}, Index: "uid_1_ctime_-1", DocsExamined: 10, KeysExamined: 10, Returned: 10},
+		},
+		{
+			name: "sort",
+			raw: bson.M{
+				"queryPlanner":   bson.M{"winningPlan": bson.M{"stage": "SORT", "inputStage": bson.M{"stage": "COLLSCAN"}}},
+				"executionStats": bson.M{"nReturned": 3, "totalKeysExamined": 0, "totalDocsExamined": 500},
+			},
+			want: Plan{Stage: "COLLSCAN", Stages: []string{"SORT", "COLLSCAN"}, DocsExamined: 500, Returned: 3, InMemorySort: true},
+		},
+		{
+			name: "or",
+			raw: bson.M{
+				"queryPlanner": bson.M{"winningPlan": bson.M{"stage": "SUBPLAN", "inputStage": bson.M{"stage": "FETCH", "inputStage": bson.M{
+					"stage": "OR",
+					"inputStages": []interface{}{
+						bson.M{"stage": "IXSCAN", "indexName": "a_1"},
+						bson.M{"stage": "IXSCAN", "indexName": "b_1"},
+					},
+				}}}},
+			},
+			want: Plan{Stage: "IXSCAN", Stages: []string{"SUBPLAN", "FETCH", "OR", "IXSCAN", "IXSCAN"}, Index: "a_1,b_1"},
+		},
+		{
+			name: "sbe",
+			raw: bson.M{
+				"queryPlanner":   bson.M{"winningPlan": bson.M{"queryPlan": bson.M{"stage": "IDHACK"}, "slotBasedPlan": bson.M{}}},
+				"executionStats": bson.M{"nReturned": 1, "totalKeysExamined": 1, "totalDocsExamined": 1},
+			},
+			want: Plan{Stage: "IDHACK", Stages: []string{"IDHACK"}, DocsExamined: 1, KeysExamined: 1, Returned: 1},
+		},
+		{
+			name: "sharded",
+			raw: bson.M{
+				"queryPlanner": bson.M{"winningPlan": bson.M{"stage": "SINGLE_SHARD", "shards": []interface{}{
+					bson.M{"shardName": "s0", "winningPlan": bson.M{"stage": "COLLSCAN"}},
+				}}},
+			},
+			want: Plan{Stage: "COLLSCAN", Stages: []string{"SINGLE_SHARD", "COLLSCAN"}},
+		},
+		{
+			name: "aggregate",
+			raw: bson.M{
+				"stages": []interface{}{
+					bson.M{"$cursor": bson.M{
+						"queryPlanner":   bson.M{"winningPlan": bson.M{"stage": "FETCH", "inputStage": bson.M{"stage": "IXSCAN", "indexName": "uid_1"}}},
+						"executionStats": bson.M{"nReturned": 20, "totalKeysExamined": 20, "totalDocsExamined": 20},
+					}},
+					bson.M{"$group": bson.M{"_id": "$age"}},
+					bson.M{"$sort": bson.M{"sortKey": bson.M{"_id": 1}}},
+				},
+			},
+			want: Plan{Stage: "IXSCAN", Stages: []string{"FETCH", "IXSCAN"}, Index: "uid_1", DocsExamined: 20, KeysExamined: 20, Returned: 20, InMemorySort: true},
+		},
+	}
+	for _, cs := range cases {
+		p := parsePlan(cs.raw)
+		p.Raw = nil
+		if !reflect.DeepEqual(*p, cs.want) {
+			t.Errorf("parsePlan %v: %+v", cs.name, *p)
+		}
+	}
+	if p := parsePlan(bson.M{"queryPlanner": bson.M{"winningPlan": bson.M{"stage": "COLLSCAN"}}}); !p.CollScan() || p.HasStage("IXSCAN") {
+		t.Errorf("CollScan: %+v", p)
+	}
+}
+
+func TestMemoryExplain(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	var plan Plan
+	ctx := WithOptions(context.Background(), WithExplain(&plan))
+	var ret []memUser
+	tot := uint32(1)
+	if err := m.FindPageCtx(ctx, "user", &tot, &ret, bson.M{"age": 25}, 0, 10, "-ctime"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ret) != 0 || tot != 0 || !plan.CollScan() || !plan.InMemorySort || plan.DocsExamined != 4 || plan.Returned != 2 {
+		t.Errorf("FindPageCtx explain: %v %v %+v", ret, tot, plan)
+	}
+
+	plan = Plan{}
+	var u memUser
+	if ok, err := m.FindOneCtx(ctx, "user", &u, bson.M{"name": "bob"}); ok || err != nil || plan.Stage != "COLLSCAN" || plan.InMemorySort {
+		t.Errorf("FindOneCtx explain: %v %v %+v", ok, err, plan)
+	}
+
+	plan = Plan{}
+	it := m.FindIterCtx(ctx, "user", nil, 0)
+	if it.Next(&u) || it.Close() != nil || plan.Returned != 4 {
+		t.Errorf("FindIterCtx explain: %+v", plan)
+	}
+
+	plan = Plan{}
+	pipeline := []bson.M{{"$match": bson.M{"age": 25}}, {"$sort": bson.M{"name": 1}}}
+	var rs []bson.M
+	if err := m.AggregateCtx(ctx, "user", &rs, pipeline); err != nil || len(rs) != 0 || !plan.InMemorySort || plan.Returned != 2 {
+		t.Errorf("AggregateCtx explain: %v %v %+v", err, rs, plan)
+	}
+
+	// 前缀字段在query中或顺序满足sort时使用索引
+	if err := m.EnsureIndexKey("user", "age", "-ctime"); err != nil {
+		t.Fatal(err)
+	}
+	plan = Plan{}
+	if err := m.FindPageCtx(ctx, "user", &tot, &ret, bson.M{"age": 25}, 0, 10, "-ctime"); err != nil ||
+		plan.Stage != "IXSCAN" || plan.Index != "age_1_ctime_-1" || plan.InMemorySort || plan.DocsExamined != 2 || plan.KeysExamined != 2 || plan.Returned != 2 {
+		t.Errorf("FindPageCtx explain with index: %v %+v", err, plan)
+	}
+	plan = Plan{}
+	if ok, err := m.FindOneCtx(ctx, "user", &u, bson.M{"name": "bob"}); ok || err != nil || !plan.CollScan() {
+		t.Errorf("FindOneCtx explain without index: %v %v %+v", ok, err, plan)
+	}
+	plan = Plan{}
+	if err := m.FindAllCtx(ctx, "user", &ret, bson.M{"age": 25}, "name"); err != nil || plan.Index != "age_1_ctime_-1" || !plan.InMemorySort ||
+		!reflect.DeepEqual(plan.Stages, []string{"SORT", "FETCH", "IXSCAN"}) {
+		t.Errorf("FindAllCtx explain in-memory sort: %v %+v", err, plan)
+	}
+	plan = Plan{}
+	if err := m.FindAllCtx(ctx, "user", &ret, nil, "-_id"); err != nil || plan.Index != "_id_" || plan.InMemorySort || plan.DocsExamined != 4 {
+		t.Errorf("FindAllCtx explain sort by index: %v %+v", err, plan)
+	}
+	plan = Plan{}
+	pipeline = []bson.M{{"$match": bson.M{"age": 25}}, {"$sort": bson.D{{Name: "age", Value: -1}, {Name: "ctime", Value: 1}}}}
+	if err := m.AggregateCtx(ctx, "user", &rs, pipeline); err != nil || plan.Index != "age_1_ctime_-1" || plan.InMemorySort {
+		t.Errorf("AggregateCtx explain with index: %v %+v", err, plan)
+	}
+
+	// 未附加WithExplain时正常执行
+	if err := m.FindAllCtx(context.Background(), "user", &ret, bson.M{"age": 25}); err != nil || len(ret) != 2 {
+		t.Errorf("FindAllCtx: %v %v", err, ret)
+	}
+}
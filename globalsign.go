@@ -1,13 +1,20 @@
 package mongo
 
 import (
+	"context"
+	"crypto/tls"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"net"
+	"sort"
+	"strings"
 	"time"
 )
 
-var EMPTY_QUERY = bson.M{}
+var (
+	EMPTY_QUERY    = bson.M{}
+	EMPTY_PIPELINE = []bson.M{}
+)
 
 type gsBulk struct {
 	*mgo.Bulk
@@ -42,12 +49,23 @@ func (b *gsBulk) Run() (matched int, modified int, err error) {
 
 type gsSession struct {
 	*Config
-	*mgo.Session
+	*mgo.Session // Lazy时为nil, 由lazy持有根会话
+	lazy         *lazySession
+	life         lifecycle
 }
 
 func (gs *gsSession) Count(c string) (n int, err error) {
 	return gs.DBCount(gs.Config.Database, c)
 }
+func (gs *gsSession) CountWhere(c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return gs.DBCountWhere(gs.Config.Database, c, query, opts...)
+}
+func (gs *gsSession) EstimatedCount(c string) (n int, err error) {
+	return gs.DBEstimatedCount(gs.Config.Database, c)
+}
+func (gs *gsSession) Exists(c string, query interface{}) (ok bool, err error) {
+	return gs.DBExists(gs.Config.Database, c, query)
+}
 func (gs *gsSession) Indexes(c string) (indexes []mgo.Index, err error) {
 	return gs.DBIndexes(gs.Config.Database, c)
 }
@@ -63,6 +81,30 @@ func (gs *gsSession) DropIndex(c string, key ...string) error {
 func (gs *gsSession) DropIndexName(c string, name string) error {
 	return gs.DBDropIndexName(gs.Config.Database, c, name)
 }
+func (gs *gsSession) CreateCollection(c string, info *mgo.CollectionInfo) error {
+	return gs.DBCreateCollection(gs.Config.Database, c, info)
+}
+func (gs *gsSession) DropCollection(c string) error {
+	return gs.DBDropCollection(gs.Config.Database, c)
+}
+func (gs *gsSession) RenameCollection(c string, to string, dropTarget bool) error {
+	return gs.DBRenameCollection(gs.Config.Database, c, to, dropTarget)
+}
+func (gs *gsSession) CollectionStats(c string) (stats *CollStats, err error) {
+	return gs.DBCollectionStats(gs.Config.Database, c)
+}
+func (gs *gsSession) ListCollections() (specs []CollectionSpec, err error) {
+	return gs.DBListCollections(gs.Config.Database)
+}
+func (gs *gsSession) DropDatabase() error {
+	return gs.DBDropDatabase(gs.Config.Database)
+}
+func (gs *gsSession) DatabaseStats() (stats *DBStats, err error) {
+	return gs.DBDatabaseStats(gs.Config.Database)
+}
+func (gs *gsSession) ListDatabases() (specs []DatabaseSpec, err error) {
+	return gs.ListDatabasesCtx(context.Background())
+}
 func (gs *gsSession) FindOne(c string, ret interface{}, query interface{}) (ok bool, err error) {
 	return gs.DBFindOne(gs.Config.Database, c, ret, query)
 }
@@ -104,6 +146,24 @@ func (gs *gsSession) SelectDistinct(c string, ret interface{}, query interface{}
 func (gs *gsSession) SelectId(c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
 	return gs.DBSelectId(gs.Config.Database, c, ret, id, projection)
 }
+func (gs *gsSession) Aggregate(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return gs.DBAggregate(gs.Config.Database, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) AggregateOne(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return gs.DBAggregateOne(gs.Config.Database, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) FindIter(c string, query interface{}, batch int, sort ...string) Iter {
+	return gs.DBFindIter(gs.Config.Database, c, query, batch, sort...)
+}
+func (gs *gsSession) SelectIter(c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return gs.DBSelectIter(gs.Config.Database, c, query, projection, batch, sort...)
+}
+func (gs *gsSession) FindKeyset(c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBFindKeyset(gs.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (gs *gsSession) SelectKeyset(c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBSelectKeyset(gs.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
 
 func (gs *gsSession) FindAndUpdate(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
 	return gs.DBFindAndUpdate(gs.Config.Database, c, ret, query, update)
@@ -156,519 +216,1329 @@ func (gs *gsSession) RunCollection(c string, f CollectionFunc, args ...interface
 	return gs.DBRunCollection(gs.Config.Database, c, f, args...)
 }
 
-func (gs *gsSession) DBCount(d string, c string) (n int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+func (gs *gsSession) CountCtx(ctx context.Context, c string) (n int, err error) {
+	return gs.DBCountCtx(ctx, gs.Config.Database, c)
+}
+func (gs *gsSession) CountWhereCtx(ctx context.Context, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return gs.DBCountWhereCtx(ctx, gs.Config.Database, c, query, opts...)
+}
+func (gs *gsSession) EstimatedCountCtx(ctx context.Context, c string) (n int, err error) {
+	return gs.DBEstimatedCountCtx(ctx, gs.Config.Database, c)
+}
+func (gs *gsSession) ExistsCtx(ctx context.Context, c string, query interface{}) (ok bool, err error) {
+	return gs.DBExistsCtx(ctx, gs.Config.Database, c, query)
+}
+func (gs *gsSession) IndexesCtx(ctx context.Context, c string) (indexes []mgo.Index, err error) {
+	return gs.DBIndexesCtx(ctx, gs.Config.Database, c)
+}
+func (gs *gsSession) EnsureIndexCtx(ctx context.Context, c string, index mgo.Index) (err error) {
+	return gs.DBEnsureIndexCtx(ctx, gs.Config.Database, c, index)
+}
+func (gs *gsSession) EnsureIndexKeyCtx(ctx context.Context, c string, key ...string) (err error) {
+	return gs.DBEnsureIndexKeyCtx(ctx, gs.Config.Database, c, key...)
+}
+func (gs *gsSession) DropIndexCtx(ctx context.Context, c string, key ...string) error {
+	return gs.DBDropIndexCtx(ctx, gs.Config.Database, c, key...)
+}
+func (gs *gsSession) DropIndexNameCtx(ctx context.Context, c string, name string) error {
+	return gs.DBDropIndexNameCtx(ctx, gs.Config.Database, c, name)
+}
+func (gs *gsSession) CreateCollectionCtx(ctx context.Context, c string, info *mgo.CollectionInfo) error {
+	return gs.DBCreateCollectionCtx(ctx, gs.Config.Database, c, info)
+}
+func (gs *gsSession) DropCollectionCtx(ctx context.Context, c string) error {
+	return gs.DBDropCollectionCtx(ctx, gs.Config.Database, c)
+}
+func (gs *gsSession) RenameCollectionCtx(ctx context.Context, c string, to string, dropTarget bool) error {
+	return gs.DBRenameCollectionCtx(ctx, gs.Config.Database, c, to, dropTarget)
+}
+func (gs *gsSession) CollectionStatsCtx(ctx context.Context, c string) (stats *CollStats, err error) {
+	return gs.DBCollectionStatsCtx(ctx, gs.Config.Database, c)
+}
+func (gs *gsSession) ListCollectionsCtx(ctx context.Context) (specs []CollectionSpec, err error) {
+	return gs.DBListCollectionsCtx(ctx, gs.Config.Database)
+}
+func (gs *gsSession) DropDatabaseCtx(ctx context.Context) error {
+	return gs.DBDropDatabaseCtx(ctx, gs.Config.Database)
+}
+func (gs *gsSession) DatabaseStatsCtx(ctx context.Context) (stats *DBStats, err error) {
+	return gs.DBDatabaseStatsCtx(ctx, gs.Config.Database)
+}
+func (gs *gsSession) FindOneCtx(ctx context.Context, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return gs.DBFindOneCtx(ctx, gs.Config.Database, c, ret, query)
+}
+func (gs *gsSession) FindAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, sort ...string) error {
+	return gs.DBFindAllCtx(ctx, gs.Config.Database, c, ret, query, sort...)
+}
+func (gs *gsSession) FindRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.DBFindRangeCtx(ctx, gs.Config.Database, c, ret, query, skip, limit, sort...)
+}
+func (gs *gsSession) FindPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.DBFindPageCtx(ctx, gs.Config.Database, c, tot, ret, query, skip, limit, sort...)
+}
+func (gs *gsSession) FindDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return gs.DBFindDistinctCtx(ctx, gs.Config.Database, c, ret, query, key, sort...)
+}
+func (gs *gsSession) FindIdCtx(ctx context.Context, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return gs.DBFindIdCtx(ctx, gs.Config.Database, c, ret, id)
+}
+func (gs *gsSession) SelectOneCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return gs.DBSelectOneCtx(ctx, gs.Config.Database, c, ret, query, projection)
+}
+func (gs *gsSession) SelectAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return gs.DBSelectAllCtx(ctx, gs.Config.Database, c, ret, query, projection, sort...)
+}
+func (gs *gsSession) SelectRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.DBSelectRangeCtx(ctx, gs.Config.Database, c, ret, query, projection, skip, limit, sort...)
+}
+func (gs *gsSession) SelectPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.DBSelectPageCtx(ctx, gs.Config.Database, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (gs *gsSession) SelectDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return gs.DBSelectDistinctCtx(ctx, gs.Config.Database, c, ret, query, projection, key, sort...)
+}
+func (gs *gsSession) SelectIdCtx(ctx context.Context, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return gs.DBSelectIdCtx(ctx, gs.Config.Database, c, ret, id, projection)
+}
+func (gs *gsSession) AggregateCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return gs.DBAggregateCtx(ctx, gs.Config.Database, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) AggregateOneCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return gs.DBAggregateOneCtx(ctx, gs.Config.Database, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) FindIterCtx(ctx context.Context, c string, query interface{}, batch int, sort ...string) Iter {
+	return gs.DBFindIterCtx(ctx, gs.Config.Database, c, query, batch, sort...)
+}
+func (gs *gsSession) SelectIterCtx(ctx context.Context, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return gs.DBSelectIterCtx(ctx, gs.Config.Database, c, query, projection, batch, sort...)
+}
+func (gs *gsSession) FindKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBFindKeysetCtx(ctx, gs.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (gs *gsSession) SelectKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBSelectKeysetCtx(ctx, gs.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
 
-	return cs.DB(d).C(c).Count()
+func (gs *gsSession) FindAndUpdateCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return gs.DBFindAndUpdateCtx(ctx, gs.Config.Database, c, ret, query, update)
+}
+func (gs *gsSession) FindAndUpsertCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return gs.DBFindAndUpsertCtx(ctx, gs.Config.Database, c, ret, query, upsert)
+}
+func (gs *gsSession) FindAndRemoveCtx(ctx context.Context, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return gs.DBFindAndRemoveCtx(ctx, gs.Config.Database, c, ret, query)
+}
+func (gs *gsSession) FindAndUpdateRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return gs.DBFindAndUpdateRNCtx(ctx, gs.Config.Database, c, ret, query, update)
+}
+func (gs *gsSession) FindAndUpsertRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return gs.DBFindAndUpsertRNCtx(ctx, gs.Config.Database, c, ret, query, upsert)
 }
-func (gs *gsSession) DBIndexes(d string, c string) (indexes []mgo.Index, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	return cs.DB(d).C(c).Indexes()
+func (gs *gsSession) InsertCtx(ctx context.Context, c string, docs ...interface{}) (err error) {
+	return gs.DBInsertCtx(ctx, gs.Config.Database, c, docs...)
+}
+func (gs *gsSession) RemoveOneCtx(ctx context.Context, c string, selector interface{}) (ok bool, err error) {
+	return gs.DBRemoveOneCtx(ctx, gs.Config.Database, c, selector)
+}
+func (gs *gsSession) RemoveAllCtx(ctx context.Context, c string, selector interface{}) (removed int, err error) {
+	return gs.DBRemoveAllCtx(ctx, gs.Config.Database, c, selector)
+}
+func (gs *gsSession) RemoveIdCtx(ctx context.Context, c string, id interface{}) (ok bool, err error) {
+	return gs.DBRemoveIdCtx(ctx, gs.Config.Database, c, id)
+}
+func (gs *gsSession) UpdateOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return gs.DBUpdateOneCtx(ctx, gs.Config.Database, c, selector, update)
+}
+func (gs *gsSession) UpdateAllCtx(ctx context.Context, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return gs.DBUpdateAllCtx(ctx, gs.Config.Database, c, selector, update)
+}
+func (gs *gsSession) UpdateIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return gs.DBUpdateIdCtx(ctx, gs.Config.Database, c, id, update)
+}
+func (gs *gsSession) UpsertOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return gs.DBUpsertOneCtx(ctx, gs.Config.Database, c, selector, update)
+}
+func (gs *gsSession) UpsertIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return gs.DBUpsertIdCtx(ctx, gs.Config.Database, c, id, update)
+}
+func (gs *gsSession) RunBulkCtx(ctx context.Context, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return gs.DBRunBulkCtx(ctx, gs.Config.Database, c, f, args...)
 }
-func (gs *gsSession) DBEnsureIndex(d string, c string, index mgo.Index) (err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	return cs.DB(d).C(c).EnsureIndex(index)
+func (gs *gsSession) RunCollectionCtx(ctx context.Context, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return gs.DBRunCollectionCtx(ctx, gs.Config.Database, c, f, args...)
 }
-func (gs *gsSession) DBEnsureIndexKey(d string, c string, key ...string) (err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	return cs.DB(d).C(c).EnsureIndexKey(key...)
+func (gs *gsSession) DBCount(d string, c string) (n int, err error) {
+	return gs.DBCountCtx(context.Background(), d, c)
+}
+func (gs *gsSession) DBCountWhere(d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return gs.DBCountWhereCtx(context.Background(), d, c, query, opts...)
+}
+func (gs *gsSession) DBEstimatedCount(d string, c string) (n int, err error) {
+	return gs.DBEstimatedCountCtx(context.Background(), d, c)
+}
+func (gs *gsSession) DBExists(d string, c string, query interface{}) (ok bool, err error) {
+	return gs.DBExistsCtx(context.Background(), d, c, query)
+}
+func (gs *gsSession) DBIndexes(d string, c string) (indexes []mgo.Index, err error) {
+	return gs.DBIndexesCtx(context.Background(), d, c)
+}
+func (gs *gsSession) DBEnsureIndex(d string, c string, index mgo.Index) (err error) {
+	return gs.DBEnsureIndexCtx(context.Background(), d, c, index)
+}
+func (gs *gsSession) DBEnsureIndexKey(d string, c string, key ...string) (err error) {
+	return gs.DBEnsureIndexKeyCtx(context.Background(), d, c, key...)
 }
 func (gs *gsSession) DBDropIndex(d string, c string, key ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	return cs.DB(d).C(c).DropIndex(key...)
+	return gs.DBDropIndexCtx(context.Background(), d, c, key...)
 }
 func (gs *gsSession) DBDropIndexName(d string, c string, name string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	return cs.DB(d).C(c).DropIndexName(name)
+	return gs.DBDropIndexNameCtx(context.Background(), d, c, name)
+}
+func (gs *gsSession) DBCreateCollection(d string, c string, info *mgo.CollectionInfo) error {
+	return gs.DBCreateCollectionCtx(context.Background(), d, c, info)
+}
+func (gs *gsSession) DBDropCollection(d string, c string) error {
+	return gs.DBDropCollectionCtx(context.Background(), d, c)
+}
+func (gs *gsSession) DBRenameCollection(d string, c string, to string, dropTarget bool) error {
+	return gs.DBRenameCollectionCtx(context.Background(), d, c, to, dropTarget)
+}
+func (gs *gsSession) DBCollectionStats(d string, c string) (stats *CollStats, err error) {
+	return gs.DBCollectionStatsCtx(context.Background(), d, c)
+}
+func (gs *gsSession) DBListCollections(d string) (specs []CollectionSpec, err error) {
+	return gs.DBListCollectionsCtx(context.Background(), d)
+}
+func (gs *gsSession) DBDropDatabase(d string) error {
+	return gs.DBDropDatabaseCtx(context.Background(), d)
+}
+func (gs *gsSession) DBDatabaseStats(d string) (stats *DBStats, err error) {
+	return gs.DBDatabaseStatsCtx(context.Background(), d)
 }
 func (gs *gsSession) DBFindOne(d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	err = cs.DB(d).C(c).Find(query).One(ret)
-	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
-		}
-	} else {
-		ok = true
-	}
-	return
+	return gs.DBFindOneCtx(context.Background(), d, c, ret, query)
 }
 func (gs *gsSession) DBFindAll(d string, c string, ret interface{}, query interface{}, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	return q.All(ret)
+	return gs.DBFindAllCtx(context.Background(), d, c, ret, query, sort...)
 }
-
 func (gs *gsSession) DBFindRange(d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	if skip > 0 {
-		q.Skip(int(skip))
-	}
-	if limit > 0 {
-		q.Limit(int(limit))
-	}
-	return q.All(ret)
+	return gs.DBFindRangeCtx(context.Background(), d, c, ret, query, skip, limit, sort...)
 }
-
 func (gs *gsSession) DBFindPage(d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query)
-	t, err := q.Count()
-	if err != nil {
-		return err
-	}
-	*tot = uint32(t)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	if skip > 0 {
-		q.Skip(int(skip))
-	}
-	if limit > 0 {
-		q.Limit(int(limit))
-	}
-	return q.All(ret)
+	return gs.DBFindPageCtx(context.Background(), d, c, tot, ret, query, skip, limit, sort...)
 }
-
 func (gs *gsSession) DBFindDistinct(d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	return q.Distinct(key, ret)
+	return gs.DBFindDistinctCtx(context.Background(), d, c, ret, query, key, sort...)
 }
-
 func (gs *gsSession) DBFindId(d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	err = cs.DB(d).C(c).FindId(id).One(ret)
-	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
-		}
-	} else {
-		ok = true
-	}
-	return
+	return gs.DBFindIdCtx(context.Background(), d, c, ret, id)
 }
-
 func (gs *gsSession) DBSelectOne(d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	err = cs.DB(d).C(c).Find(query).Select(projection).One(ret)
-	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
-		}
-	} else {
-		ok = true
-	}
-	return
+	return gs.DBSelectOneCtx(context.Background(), d, c, ret, query, projection)
 }
 func (gs *gsSession) DBSelectAll(d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query).Select(projection)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	return q.All(ret)
+	return gs.DBSelectAllCtx(context.Background(), d, c, ret, query, projection, sort...)
 }
 func (gs *gsSession) DBSelectRange(d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query).Select(projection)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	if skip > 0 {
-		q.Skip(int(skip))
-	}
-	if limit > 0 {
-		q.Limit(int(limit))
-	}
-	return q.All(ret)
+	return gs.DBSelectRangeCtx(context.Background(), d, c, ret, query, projection, skip, limit, sort...)
 }
 func (gs *gsSession) DBSelectPage(d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	if projection == nil {
-		projection = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query).Select(projection)
-	t, err := q.Count()
-	if err != nil {
-		return err
-	}
-	*tot = uint32(t)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	if skip > 0 {
-		q.Skip(int(skip))
-	}
-	if limit > 0 {
-		q.Limit(int(limit))
-	}
-	return q.All(ret)
+	return gs.DBSelectPageCtx(context.Background(), d, c, tot, ret, query, projection, skip, limit, sort...)
 }
 func (gs *gsSession) DBSelectDistinct(d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	if projection == nil {
-		projection = EMPTY_QUERY
-	}
-	q := cs.DB(d).C(c).Find(query).Select(projection)
-	if len(sort) > 0 {
-		q.Sort(sort...)
-	}
-	return q.Distinct(key, ret)
+	return gs.DBSelectDistinctCtx(context.Background(), d, c, ret, query, projection, key, sort...)
 }
 func (gs *gsSession) DBSelectId(d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	err = cs.DB(d).C(c).FindId(id).Select(projection).One(ret)
-	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
-		}
-	} else {
-		ok = true
-	}
-	return
+	return gs.DBSelectIdCtx(context.Background(), d, c, ret, id, projection)
+}
+func (gs *gsSession) DBAggregate(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return gs.DBAggregateCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) DBAggregateOne(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return gs.DBAggregateOneCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+func (gs *gsSession) DBFindIter(d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return gs.DBFindIterCtx(context.Background(), d, c, query, batch, sort...)
+}
+func (gs *gsSession) DBSelectIter(d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return gs.DBSelectIterCtx(context.Background(), d, c, query, projection, batch, sort...)
+}
+func (gs *gsSession) DBFindKeyset(d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBFindKeysetCtx(context.Background(), d, c, ret, query, token, limit, sort...)
+}
+func (gs *gsSession) DBSelectKeyset(d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return gs.DBSelectKeysetCtx(context.Background(), d, c, ret, query, projection, token, limit, sort...)
 }
-
 func (gs *gsSession) DBFindAndUpdate(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+	return gs.DBFindAndUpdateCtx(context.Background(), d, c, ret, query, update)
+}
+func (gs *gsSession) DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return gs.DBFindAndUpsertCtx(context.Background(), d, c, ret, query, upsert)
+}
+func (gs *gsSession) DBFindAndRemove(d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return gs.DBFindAndRemoveCtx(context.Background(), d, c, ret, query)
+}
+func (gs *gsSession) DBFindAndUpdateRN(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return gs.DBFindAndUpdateRNCtx(context.Background(), d, c, ret, query, update)
+}
+func (gs *gsSession) DBFindAndUpsertRN(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return gs.DBFindAndUpsertRNCtx(context.Background(), d, c, ret, query, upsert)
+}
+func (gs *gsSession) DBInsert(d string, c string, docs ...interface{}) (err error) {
+	return gs.DBInsertCtx(context.Background(), d, c, docs...)
+}
+func (gs *gsSession) DBRemoveOne(d string, c string, selector interface{}) (ok bool, err error) {
+	return gs.DBRemoveOneCtx(context.Background(), d, c, selector)
+}
+func (gs *gsSession) DBRemoveAll(d string, c string, selector interface{}) (removed int, err error) {
+	return gs.DBRemoveAllCtx(context.Background(), d, c, selector)
+}
+func (gs *gsSession) DBRemoveId(d string, c string, id interface{}) (ok bool, err error) {
+	return gs.DBRemoveIdCtx(context.Background(), d, c, id)
+}
+func (gs *gsSession) DBUpdateOne(d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return gs.DBUpdateOneCtx(context.Background(), d, c, selector, update)
+}
+func (gs *gsSession) DBUpdateAll(d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return gs.DBUpdateAllCtx(context.Background(), d, c, selector, update)
+}
+func (gs *gsSession) DBUpdateId(d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return gs.DBUpdateIdCtx(context.Background(), d, c, id, update)
+}
+func (gs *gsSession) DBUpsertOne(d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return gs.DBUpsertOneCtx(context.Background(), d, c, selector, update)
+}
+func (gs *gsSession) DBUpsertId(d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return gs.DBUpsertIdCtx(context.Background(), d, c, id, update)
+}
+func (gs *gsSession) DBRunBulk(d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return gs.DBRunBulkCtx(context.Background(), d, c, f, args...)
+}
+func (gs *gsSession) DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return gs.DBRunCollectionCtx(context.Background(), d, c, f, args...)
+}
+func (gs *gsSession) RunSession(f SessionFunc, args ...interface{}) (interface{}, error) {
+	return gs.RunSessionCtx(context.Background(), f, args...)
+}
 
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	ci, err := cs.DB(d).C(c).Find(query).Apply(mgo.Change{
-		Update:    update,
-		Upsert:    false,
-		Remove:    false,
-		ReturnNew: false,
-	}, ret)
+func (gs *gsSession) DBCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	var rn int
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		rn, err = ex.find(d, c, nil).Count()
+		return
+	})
 	if err != nil {
 		return
 	}
-	updated = ci.Updated
+	n = rn
 	return
 }
-func (gs *gsSession) DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
-	}
-	ci, err := cs.DB(d).C(c).Find(query).Apply(mgo.Change{
-		Update:    upsert,
-		Upsert:    true,
-		Remove:    false,
-		ReturnNew: false,
-	}, ret)
+func (gs *gsSession) DBCountWhereCtx(ctx context.Context, d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	opt := countOptions(opts)
+	var rn int
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		q := ex.find(d, c, query)
+		if opt.Skip > 0 {
+			q.Skip(int(opt.Skip))
+		}
+		if opt.Limit > 0 {
+			q.Limit(int(opt.Limit))
+		}
+		if len(opt.Hint) > 0 {
+			q.Hint(opt.Hint...)
+		}
+		rn, err = q.Count()
+		return
+	})
 	if err != nil {
 		return
 	}
-	upsertedId = ci.UpsertedId
+	n = rn
 	return
 }
-func (gs *gsSession) DBFindAndRemove(d string, c string, ret interface{}, query interface{}) (removed int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
+func (gs *gsSession) DBEstimatedCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	var rn int
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		// 不带query的count命令直接读取集合元数据, 不扫描文档
+		cmd := bson.D{{Name: "count", Value: c}}
+		if ex.maxTime > 0 {
+			cmd = append(cmd, bson.DocElem{Name: "maxTimeMS", Value: int64(ex.maxTime / time.Millisecond)})
+		}
+		var result struct{ N int }
+		err = ex.DB(d).Run(cmd, &result)
+		rn = result.N
+		return
+	})
+	if err != nil {
+		return
 	}
-	ci, err := cs.DB(d).C(c).Find(query).Apply(mgo.Change{
-		Update:    nil,
-		Upsert:    false,
-		Remove:    true,
-		ReturnNew: false,
-	}, ret)
+	n = rn
+	return
+}
+func (gs *gsSession) DBExistsCtx(ctx context.Context, d string, c string, query interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		var doc bson.M
+		found, err = notFound(ex.find(d, c, query).Select(bson.M{"_id": 1}).Limit(1).One(&doc))
+		return
+	})
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-		}
 		return
 	}
-	removed = ci.Removed
+	ok = found
 	return
 }
-func (gs *gsSession) DBFindAndUpdateRN(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
+func (gs *gsSession) DBIndexesCtx(ctx context.Context, d string, c string) (indexes []mgo.Index, err error) {
+	var rs []mgo.Index
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		rs, err = ex.DB(d).C(c).Indexes()
+		return
+	})
+	if err != nil {
+		return
+	}
+	indexes = rs
+	return
+}
+func (gs *gsSession) DBEnsureIndexCtx(ctx context.Context, d string, c string, index mgo.Index) (err error) {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).EnsureIndex(index)
+	})
+}
+func (gs *gsSession) DBEnsureIndexKeyCtx(ctx context.Context, d string, c string, key ...string) (err error) {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).EnsureIndexKey(key...)
+	})
+}
+func (gs *gsSession) DBDropIndexCtx(ctx context.Context, d string, c string, key ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).DropIndex(key...)
+	})
+}
+func (gs *gsSession) DBDropIndexNameCtx(ctx context.Context, d string, c string, name string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).DropIndexName(name)
+	})
+}
+func (gs *gsSession) DBCreateCollectionCtx(ctx context.Context, d string, c string, info *mgo.CollectionInfo) error {
+	if info == nil {
+		info = new(mgo.CollectionInfo)
 	}
-	ci, err := cs.DB(d).C(c).Find(query).Apply(mgo.Change{
-		Update:    update,
-		Upsert:    false,
-		Remove:    false,
-		ReturnNew: true,
-	}, ret)
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).Create(info)
+	})
+}
+func (gs *gsSession) DBDropCollectionCtx(ctx context.Context, d string, c string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		if err := ex.DB(d).C(c).DropCollection(); err != nil && !nsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+func (gs *gsSession) DBRenameCollectionCtx(ctx context.Context, d string, c string, to string, dropTarget bool) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.Run(bson.D{
+			{Name: "renameCollection", Value: d + "." + c},
+			{Name: "to", Value: d + "." + to},
+			{Name: "dropTarget", Value: dropTarget},
+		}, nil)
+	})
+}
+func (gs *gsSession) DBCollectionStatsCtx(ctx context.Context, d string, c string) (stats *CollStats, err error) {
+	var rs CollStats
+	err = gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).Run(bson.D{{Name: "collStats", Value: c}}, &rs)
+	})
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
+		return
+	}
+	stats = &rs
+	return
+}
+func (gs *gsSession) DBListCollectionsCtx(ctx context.Context, d string) (specs []CollectionSpec, err error) {
+	var rs []CollectionSpec
+	err = gs.exec(ctx, func(ex *gsExec) error {
+		var result struct {
+			Cursor struct {
+				FirstBatch []bson.Raw `bson:"firstBatch"`
+				NS         string
+				Id         int64
+			}
+		}
+		db := ex.DB(d)
+		if err := db.Run(bson.D{{Name: "listCollections", Value: 1}, {Name: "cursor", Value: bson.M{}}}, &result); err != nil {
+			return err
 		}
+		// 结果较多时需通过游标继续读取, 同mgo的CollectionNames
+		cl := db.C("$cmd.listCollections")
+		if ns := strings.SplitN(result.Cursor.NS, ".", 2); len(ns) == 2 {
+			cl = ex.DB(ns[0]).C(ns[1])
+		}
+		it := cl.NewIter(nil, result.Cursor.FirstBatch, result.Cursor.Id, nil)
+		var doc collectionDoc
+		for it.Next(&doc) {
+			rs = append(rs, doc.spec())
+			doc = collectionDoc{}
+		}
+		return it.Close()
+	})
+	if err != nil {
 		return
 	}
-	updated = ci.Updated
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	specs = rs
 	return
 }
-func (gs *gsSession) DBFindAndUpsertRN(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	if query == nil {
-		query = EMPTY_QUERY
+func (gs *gsSession) DBDropDatabaseCtx(ctx context.Context, d string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).DropDatabase()
+	})
+}
+func (gs *gsSession) DBDatabaseStatsCtx(ctx context.Context, d string) (stats *DBStats, err error) {
+	var rs DBStats
+	err = gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).Run(bson.D{{Name: "dbStats", Value: 1}}, &rs)
+	})
+	if err != nil {
+		return
+	}
+	stats = &rs
+	return
+}
+func (gs *gsSession) ListDatabasesCtx(ctx context.Context) (specs []DatabaseSpec, err error) {
+	var result struct {
+		Databases []DatabaseSpec `bson:"databases"`
 	}
-	ci, err := cs.DB(d).C(c).Find(query).Apply(mgo.Change{
-		Update:    upsert,
-		Upsert:    true,
-		Remove:    false,
-		ReturnNew: true,
-	}, ret)
+	err = gs.exec(ctx, func(ex *gsExec) error {
+		return ex.Run(bson.D{{Name: "listDatabases", Value: 1}}, &result)
+	})
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-		}
 		return
 	}
-	upsertedId = ci.UpsertedId
+	sort.Slice(result.Databases, func(i, j int) bool { return result.Databases[i].Name < result.Databases[j].Name })
+	specs = result.Databases
+	return
+}
+func (gs *gsSession) DBFindOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = ex.one(ex.find(d, c, query), ret)
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
 	return
 }
+func (gs *gsSession) DBFindAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		return ex.all(q, ret)
+	})
+}
 
-func (gs *gsSession) DBInsert(d string, c string, docs ...interface{}) (err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+func (gs *gsSession) DBFindRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		if skip > 0 {
+			q.Skip(int(skip))
+		}
+		if limit > 0 {
+			q.Limit(int(limit))
+		}
+		return ex.all(q, ret)
+	})
+}
 
-	return cs.DB(d).C(c).Insert(docs...)
+func (gs *gsSession) DBFindPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query)
+		t, err := ex.count(q)
+		if err != nil {
+			return err
+		}
+		*tot = uint32(t)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		if skip > 0 {
+			q.Skip(int(skip))
+		}
+		if limit > 0 {
+			q.Limit(int(limit))
+		}
+		return ex.all(q, ret)
+	})
 }
-func (gs *gsSession) DBRemoveOne(d string, c string, selector interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	err = cs.DB(d).C(c).Remove(selector)
+func (gs *gsSession) DBFindDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		return ex.distinct(q, key, ret)
+	})
+}
+
+func (gs *gsSession) DBFindIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = ex.one(ex.findId(d, c, id), ret)
+		return
+	})
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
+		return
+	}
+	ok = found
+	return
+}
+
+func (gs *gsSession) DBSelectOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = ex.one(ex.find(d, c, query).Select(projection), ret)
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
+	return
+}
+func (gs *gsSession) DBSelectAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query).Select(projection)
+		if len(sort) > 0 {
+			q.Sort(sort...)
 		}
-	} else {
-		ok = true
+		return ex.all(q, ret)
+	})
+}
+func (gs *gsSession) DBSelectRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query).Select(projection)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		if skip > 0 {
+			q.Skip(int(skip))
+		}
+		if limit > 0 {
+			q.Limit(int(limit))
+		}
+		return ex.all(q, ret)
+	})
+}
+func (gs *gsSession) DBSelectPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	if projection == nil {
+		projection = EMPTY_QUERY
+	}
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query).Select(projection)
+		t, err := ex.count(q)
+		if err != nil {
+			return err
+		}
+		*tot = uint32(t)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		if skip > 0 {
+			q.Skip(int(skip))
+		}
+		if limit > 0 {
+			q.Limit(int(limit))
+		}
+		return ex.all(q, ret)
+	})
+}
+func (gs *gsSession) DBSelectDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	if projection == nil {
+		projection = EMPTY_QUERY
 	}
+	return gs.exec(ctx, func(ex *gsExec) error {
+		q := ex.find(d, c, query).Select(projection)
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		return ex.distinct(q, key, ret)
+	})
+}
+func (gs *gsSession) DBSelectIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = ex.one(ex.findId(d, c, id).Select(projection), ret)
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
 	return
 }
-func (gs *gsSession) DBRemoveAll(d string, c string, selector interface{}) (removed int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	ci, err := cs.DB(d).C(c).RemoveAll(selector)
+func (gs *gsSession) DBAggregateCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		if ex.explaining() {
+			return ex.explainPipe(d, c, pipeline, pipeOptions(opts))
+		}
+		return ex.pipe(d, c, pipeline, pipeOptions(opts)).All(ret)
+	})
+}
+func (gs *gsSession) DBAggregateOneCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		if ex.explaining() {
+			return ex.explainPipe(d, c, pipeline, pipeOptions(opts))
+		}
+		found, err = notFound(ex.pipe(d, c, pipeline, pipeOptions(opts)).One(ret))
+		return
+	})
 	if err != nil {
 		return
 	}
-	removed = ci.Removed
+	ok = found
 	return
 }
-func (gs *gsSession) DBRemoveId(d string, c string, id interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+func (gs *gsSession) DBFindIterCtx(ctx context.Context, d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return gs.DBSelectIterCtx(ctx, d, c, query, nil, batch, sort...)
+}
+func (gs *gsSession) DBSelectIterCtx(ctx context.Context, d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	build := func(ex *gsExec) *mgo.Query {
+		q := ex.find(d, c, query).Select(projection)
+		if batch > 0 {
+			q.Batch(batch)
+		}
+		if len(sort) > 0 {
+			q.Sort(sort...)
+		}
+		return q
+	}
+	if o := callOptionsFrom(ctx); o != nil && o.explain != nil {
+		return &errIter{err: gs.exec(ctx, func(ex *gsExec) error {
+			return ex.explain(build(ex))
+		})}
+	}
+	return gs.iter(ctx, func(ex *gsExec) *mgo.Iter {
+		return build(ex).Iter()
+	})
+}
+func (gs *gsSession) DBFindKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return keyset(ctx, gs, d, c, ret, query, nil, token, limit, sort)
+}
+func (gs *gsSession) DBSelectKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return keyset(ctx, gs, d, c, ret, query, projection, token, limit, sort)
+}
 
-	err = cs.DB(d).C(c).RemoveId(id)
+func (gs *gsSession) DBFindAndUpdateCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.find(d, c, query).Apply(mgo.Change{
+			Update:    update,
+			Upsert:    false,
+			Remove:    false,
+			ReturnNew: false,
+		}, ret)
+		return
+	})
+	if err != nil {
+		return
+	}
+	updated = ci.Updated
+	return
+}
+func (gs *gsSession) DBFindAndUpsertCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	if err = gs.checkUpdate(upsert); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.find(d, c, query).Apply(mgo.Change{
+			Update:    upsert,
+			Upsert:    true,
+			Remove:    false,
+			ReturnNew: false,
+		}, ret)
+		return
+	})
+	if err != nil {
+		return
+	}
+	upsertedId = ci.UpsertedId
+	return
+}
+func (gs *gsSession) DBFindAndRemoveCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.find(d, c, query).Apply(mgo.Change{
+			Update:    nil,
+			Upsert:    false,
+			Remove:    true,
+			ReturnNew: false,
+		}, ret)
+		return
+	})
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			err = nil
-			ok = false
-		} else {
-			return
 		}
-	} else {
-		ok = true
+		return
 	}
+	removed = ci.Removed
 	return
 }
-func (gs *gsSession) DBUpdateOne(d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
-
-	err = cs.DB(d).C(c).Update(selector, update)
+func (gs *gsSession) DBFindAndUpdateRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.find(d, c, query).Apply(mgo.Change{
+			Update:    update,
+			Upsert:    false,
+			Remove:    false,
+			ReturnNew: true,
+		}, ret)
+		return
+	})
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			err = nil
-			ok = false
-		} else {
-			return
 		}
-	} else {
-		ok = true
+		return
 	}
+	updated = ci.Updated
+	return
+}
+func (gs *gsSession) DBFindAndUpsertRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	if err = gs.checkUpdate(upsert); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.find(d, c, query).Apply(mgo.Change{
+			Update:    upsert,
+			Upsert:    true,
+			Remove:    false,
+			ReturnNew: true,
+		}, ret)
+		return
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	upsertedId = ci.UpsertedId
 	return
 }
-func (gs *gsSession) DBUpdateAll(d string, c string, selector interface{}, update interface{}) (updated int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	ci, err := cs.DB(d).C(c).UpdateAll(selector, update)
+func (gs *gsSession) DBInsertCtx(ctx context.Context, d string, c string, docs ...interface{}) (err error) {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.DB(d).C(c).Insert(docs...)
+	})
+}
+func (gs *gsSession) DBRemoveOneCtx(ctx context.Context, d string, c string, selector interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = notFound(ex.DB(d).C(c).Remove(selector))
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
+	return
+}
+func (gs *gsSession) DBRemoveAllCtx(ctx context.Context, d string, c string, selector interface{}) (removed int, err error) {
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.DB(d).C(c).RemoveAll(selector)
+		return
+	})
+	if err != nil {
+		return
+	}
+	removed = ci.Removed
+	return
+}
+func (gs *gsSession) DBRemoveIdCtx(ctx context.Context, d string, c string, id interface{}) (ok bool, err error) {
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = notFound(ex.DB(d).C(c).RemoveId(id))
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
+	return
+}
+func (gs *gsSession) DBUpdateOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = notFound(ex.DB(d).C(c).Update(selector, update))
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
+	return
+}
+func (gs *gsSession) DBUpdateAllCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.DB(d).C(c).UpdateAll(selector, update)
+		return
+	})
 	if err != nil {
 		return
 	}
 	updated = ci.Updated
 	return
 }
-func (gs *gsSession) DBUpdateId(d string, c string, id interface{}, update interface{}) (ok bool, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+func (gs *gsSession) DBUpdateIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var found bool
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		found, err = notFound(ex.DB(d).C(c).UpdateId(id, update))
+		return
+	})
+	if err != nil {
+		return
+	}
+	ok = found
+	return
+}
+func (gs *gsSession) DBUpsertOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.DB(d).C(c).Upsert(selector, update)
+		return
+	})
+	if err != nil {
+		return
+	}
+	upsertId = ci
+	return
+}
+func (gs *gsSession) DBUpsertIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	if err = gs.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		ci, err = ex.DB(d).C(c).UpsertId(id, update)
+		return
+	})
+	if err != nil {
+		return
+	}
+	upsertId = ci
+	return
+}
+func (gs *gsSession) DBRunBulkCtx(ctx context.Context, d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	var rs *mgo.BulkResult
+	err = gs.exec(ctx, func(ex *gsExec) (err error) {
+		bk := ex.DB(d).C(c).Bulk()
+		f(&gsBulk{Bulk: bk}, args...)
+		rs, err = bk.Run()
+		return
+	})
+	if rs != nil && err == nil {
+		matched = rs.Matched
+		modified = rs.Modified
+	}
+	return
+}
 
-	err = cs.DB(d).C(c).UpdateId(id, update)
+func (gs *gsSession) DBRunCollectionCtx(ctx context.Context, d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	var ret interface{}
+	err := gs.exec(ctx, func(ex *gsExec) (err error) {
+		ret, err = f(ex.DB(d).C(c), args...)
+		return
+	})
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			err = nil
-			ok = false
-		} else {
-			return
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (gs *gsSession) RunSessionCtx(ctx context.Context, f SessionFunc, args ...interface{}) (interface{}, error) {
+	var ret interface{}
+	err := gs.exec(ctx, func(ex *gsExec) (err error) {
+		ret, err = f(ex.Session, args...)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (gs *gsSession) Ping(ctx context.Context) error {
+	return gs.exec(ctx, func(ex *gsExec) error {
+		return ex.Ping()
+	})
+}
+
+func (gs *gsSession) Close() error {
+	<-gs.life.shutdown()
+	if gs.lazy != nil {
+		gs.lazy.close()
+	} else {
+		gs.Session.Close()
+	}
+	return nil
+}
+
+// Refresh 刷新根会话. Lazy且尚未连接时忽略
+func (gs *gsSession) Refresh() {
+	if gs.lazy != nil {
+		if ms := gs.lazy.current(); ms != nil {
+			ms.Refresh()
 		}
 	} else {
-		ok = true
+		gs.Session.Refresh()
 	}
-	return
 }
-func (gs *gsSession) DBUpsertOne(d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	return cs.DB(d).C(c).Upsert(selector, update)
+// gsExec 单次调用的执行环境: 拷贝的会话及由ctx推导出的服务端maxTimeMS
+type gsExec struct {
+	*mgo.Session
+	maxTime time.Duration
+	opts    *callOptions // WithOptions附加的调用选项, 可为nil
 }
-func (gs *gsSession) DBUpsertId(d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	return cs.DB(d).C(c).UpsertId(id, update)
+func (ex *gsExec) find(d string, c string, query interface{}) *mgo.Query {
+	if query == nil {
+		query = EMPTY_QUERY
+	}
+	return ex.query(ex.DB(d).C(c).Find(query))
 }
-func (gs *gsSession) DBRunBulk(d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
 
-	bk := cs.DB(d).C(c).Bulk()
-	f(&gsBulk{Bulk: bk}, args...)
-	rs, err := bk.Run()
-	if rs != nil && err == nil {
-		matched = rs.Matched
-		modified = rs.Modified
+func (ex *gsExec) findId(d string, c string, id interface{}) *mgo.Query {
+	return ex.query(ex.DB(d).C(c).FindId(id))
+}
+
+func (ex *gsExec) pipe(d string, c string, pipeline interface{}, opt *PipeOptions) *mgo.Pipe {
+	if pipeline == nil {
+		pipeline = EMPTY_PIPELINE
+	}
+	p := ex.DB(d).C(c).Pipe(pipeline)
+	if opt.AllowDiskUse {
+		p.AllowDiskUse()
+	}
+	if opt.BatchSize > 0 {
+		p.Batch(opt.BatchSize)
+	} else if ex.opts != nil && ex.opts.batch > 0 {
+		p.Batch(ex.opts.batch)
+	}
+	if ex.opts != nil && ex.opts.collation != nil {
+		p.Collation(ex.opts.collation)
+	}
+	if mt := opt.MaxTime; mt > 0 || ex.maxTime > 0 {
+		if mt <= 0 || ex.maxTime > 0 && ex.maxTime < mt {
+			mt = ex.maxTime
+		}
+		p.SetMaxTime(mt)
+	}
+	return p
+}
+
+func (ex *gsExec) query(q *mgo.Query) *mgo.Query {
+	if ex.maxTime > 0 {
+		q.SetMaxTime(ex.maxTime)
+	}
+	if o := ex.opts; o != nil {
+		if len(o.hint) > 0 {
+			q.Hint(o.hint...)
+		}
+		if o.collation != nil {
+			q.Collation(o.collation)
+		}
+		if o.comment != "" {
+			q.Comment(o.comment)
+		}
+		if o.batch > 0 {
+			q.Batch(o.batch)
+		}
+	}
+	return q
+}
+
+// explaining 是否以explain代替执行, 见WithExplain
+func (ex *gsExec) explaining() bool {
+	return ex.opts != nil && ex.opts.explain != nil
+}
+
+func (ex *gsExec) explain(q *mgo.Query) error {
+	var raw bson.M
+	if err := q.Explain(&raw); err != nil {
+		return err
+	}
+	*ex.opts.explain = *parsePlan(raw)
+	return nil
+}
+
+// explainPipe mgo的Pipe.Explain不返回executionStats, 直接执行explain命令
+func (ex *gsExec) explainPipe(d string, c string, pipeline interface{}, opt *PipeOptions) error {
+	if pipeline == nil {
+		pipeline = EMPTY_PIPELINE
+	}
+	cmd := bson.D{{Name: "aggregate", Value: c}, {Name: "pipeline", Value: pipeline}, {Name: "cursor", Value: bson.M{}}}
+	if opt.AllowDiskUse {
+		cmd = append(cmd, bson.DocElem{Name: "allowDiskUse", Value: true})
+	}
+	if ex.opts.collation != nil {
+		cmd = append(cmd, bson.DocElem{Name: "collation", Value: ex.opts.collation})
+	}
+	if ex.maxTime > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "maxTimeMS", Value: int64(ex.maxTime / time.Millisecond)})
+	}
+	var raw bson.M
+	if err := ex.DB(d).Run(bson.D{{Name: "explain", Value: cmd}, {Name: "verbosity", Value: "executionStats"}}, &raw); err != nil {
+		return err
+	}
+	*ex.opts.explain = *parsePlan(raw)
+	return nil
+}
+
+func (ex *gsExec) one(q *mgo.Query, ret interface{}) (bool, error) {
+	if ex.explaining() {
+		return false, ex.explain(q.Limit(1))
+	}
+	return notFound(q.One(ret))
+}
+
+func (ex *gsExec) all(q *mgo.Query, ret interface{}) error {
+	if ex.explaining() {
+		return ex.explain(q)
+	}
+	return q.All(ret)
+}
+
+func (ex *gsExec) count(q *mgo.Query) (int, error) {
+	if ex.explaining() {
+		return 0, nil
+	}
+	return q.Count()
+}
+
+func (ex *gsExec) distinct(q *mgo.Query, key string, ret interface{}) error {
+	if ex.explaining() {
+		return ex.explain(q)
+	}
+	return q.Distinct(key, ret)
+}
+
+/*
+exec 在拷贝的会话上执行f:
+1. ctx的deadline转为查询的maxTimeMS, 由服务端中止超时操作
+2. ctx取消时立即关闭拷贝会话并返回ctx.Err(). 此时后台操作可能仍在写入ret, 调用方应丢弃ret
+3. 后台操作结束后才释放会话及调用计数, Close不会在其仍在使用会话时关闭根会话
+*/
+func (gs *gsSession) exec(ctx context.Context, f func(ex *gsExec) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := gs.life.acquire(); err != nil {
+		return err
+	}
+	ex, err := gs.copy(ctx)
+	if err != nil {
+		gs.life.release()
+		return err
+	}
+	return runCtx(ctx, func() error {
+		return f(ex)
+	}, ex.Close, func() {
+		ex.Close()
+		gs.life.release()
+	})
+}
+
+/*
+runCtx 执行f, ctx可取消时f在单独的goroutine中执行:
+1. ctx取消时调用abort中断f, 并立即返回ctx.Err()
+2. f结束时(包括取消之后)调用done
+3. f的panic在调用方goroutine中重新抛出; 取消后的panic(如使用已关闭的会话)被忽略
+*/
+func runCtx(ctx context.Context, f func() error, abort func(), done func()) error {
+	if ctx.Done() == nil {
+		defer done()
+		return f()
+	}
+
+	type result struct {
+		err      error
+		panicked bool
+		value    interface{}
+	}
+	ch := make(chan result, 1)
+	go func() {
+		defer done()
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- result{panicked: true, value: r}
+			}
+		}()
+		ch <- result{err: f()}
+	}()
+	select {
+	case res := <-ch:
+		if res.panicked {
+			panic(res.value)
+		}
+		return res.err
+	case <-ctx.Done():
+		abort()
+		return ctx.Err()
+	}
+}
+
+// copy 拷贝会话, 并将ctx的deadline转为maxTimeMS
+func (gs *gsSession) copy(ctx context.Context) (*gsExec, error) {
+	root := gs.Session
+	if gs.lazy != nil {
+		var err error
+		if root, err = gs.lazy.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+	ex := &gsExec{Session: root.Copy(), maxTime: maxTimeOf(ctx)}
+	if o := callOptionsFrom(ctx); o != nil {
+		ex.opts = o
+		if o.mode != nil {
+			ex.SetMode(*o.mode, true)
+		}
+		if o.safeSet {
+			ex.SetSafe(o.safe)
+		}
+	}
+	return ex, nil
+}
+
+// maxTimeOf 由ctx的deadline及WithMaxTime得出服务端maxTimeMS, 取较小值, 0表示不限制
+func maxTimeOf(ctx context.Context) (mt time.Duration) {
+	if dl, ok := ctx.Deadline(); ok {
+		mt = time.Until(dl)
+		if mt < time.Millisecond {
+			mt = time.Millisecond // maxTimeMS最小精度为毫秒, 0表示不限制
+		}
+	}
+	if o := callOptionsFrom(ctx); o != nil && o.maxTime > 0 && (mt == 0 || o.maxTime < mt) {
+		mt = o.maxTime
 	}
 	return
 }
 
-func (gs *gsSession) DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+/*
+iter 在拷贝的会话上创建迭代器, 会话由迭代器持有并在Close时释放, 未Close的迭代器计为进行中的调用.
+与exec不同, 取消ctx不会中断正在进行的getMore, 只在下一次Next时生效, 因此带deadline的ctx会同时设置maxTimeMS
+*/
+func (gs *gsSession) iter(ctx context.Context, f func(ex *gsExec) *mgo.Iter) Iter {
+	if err := ctx.Err(); err != nil {
+		return &errIter{err: err}
+	}
+	if err := gs.life.acquire(); err != nil {
+		return &errIter{err: err}
+	}
+	ex, err := gs.copy(ctx)
+	if err != nil {
+		gs.life.release()
+		return &errIter{err: err}
+	}
+	return &gsIter{Iter: f(ex), ctx: ctx, session: ex.Session, release: gs.life.release}
+}
 
-	cl := cs.DB(d).C(c)
-	return f(cl, args...)
+type gsIter struct {
+	*mgo.Iter
+	ctx     context.Context
+	session *mgo.Session
+	release func()
+	err     error
 }
 
-func (gs *gsSession) RunSession(f SessionFunc, args ...interface{}) (interface{}, error) {
-	cs := gs.Session.Copy()
-	defer cs.Close()
+func (it *gsIter) Next(ret interface{}) bool {
+	if it.session == nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+	return it.Iter.Next(ret)
+}
 
-	return f(cs, args...)
+func (it *gsIter) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iter.Err()
+}
+
+func (it *gsIter) Close() error {
+	if it.session != nil {
+		if err := it.Iter.Close(); err != nil && it.err == nil {
+			it.err = err
+		}
+		it.session.Close()
+		it.session = nil
+		it.release()
+	}
+	return it.Err()
+}
+
+func notFound(err error) (bool, error) {
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 func newGlobalsignMongo(opt *Config) (*gsSession, error) {
-	dsf := func(addr *mgo.ServerAddr) (net.Conn, error) {
-		tcp, err := net.DialTCP("tcp", nil, addr.TCPAddr())
-		if err != nil {
+	var tlsConfig *tls.Config
+	if opt.TLS != nil {
+		var err error
+		if tlsConfig, err = opt.TLS.config(); err != nil {
 			return nil, err
 		}
-		if opt.Keepalive > 0 {
-			tcp.SetKeepAlive(true)
-			tcp.SetKeepAlivePeriod(opt.Keepalive)
-		}
-		return tcp, nil
+	}
+	dsf := func(addr *mgo.ServerAddr) (net.Conn, error) {
+		return dialTCP(opt, tlsConfig, addr.String(), addr.TCPAddr())
 	}
 	di := &mgo.DialInfo{
 		Addrs:    opt.Address,
@@ -677,6 +1547,8 @@ func newGlobalsignMongo(opt *Config) (*gsSession, error) {
 		Password: opt.Password,
 		Source:   opt.Source,
 
+		ReplicaSetName: opt.ReplicaSet,
+
 		Timeout:      opt.ConnectTimeout,
 		DialServer:   dsf,
 		WriteTimeout: opt.WriteTimeout,
@@ -687,11 +1559,21 @@ func newGlobalsignMongo(opt *Config) (*gsSession, error) {
 		PoolTimeout:   time.Duration(opt.MaxPoolWaitTimeMS) * time.Millisecond,
 		MaxIdleTimeMS: opt.MaxPoolIdleTimeMS,
 	}
-	ms, err := mgo.DialWithInfo(di)
+	dial := func() (*mgo.Session, error) {
+		ms, err := mgo.DialWithInfo(di)
+		if err != nil {
+			return nil, err
+		}
+		ms.SetSafe(opt.Safe)       //数据安全. 参考https://godoc.org/github.com/globalsign/mgo#Safe
+		ms.SetMode(opt.Mode, true) // 读写时序. 参考https://docs.mongodb.com/manual/reference/read-preference/
+		return ms, nil
+	}
+	if opt.Lazy {
+		return &gsSession{Config: opt, lazy: newLazySession(dial)}, nil
+	}
+	ms, err := dial()
 	if err != nil {
 		return nil, err
 	}
-	ms.SetSafe(opt.Safe)       //数据安全. 参考https://godoc.org/github.com/globalsign/mgo#Safe
-	ms.SetMode(opt.Mode, true) // 读写时序. 参考https://docs.mongodb.com/manual/reference/read-preference/
 	return &gsSession{Session: ms, Config: opt}, nil
 }
@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunCtx(t *testing.T) {
+	// 取消时立即返回ctx.Err(), f结束后才调用done
+	ctx, cancel := context.WithCancel(context.Background())
+	release, aborted, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := runCtx(ctx, func() error {
+		<-release
+		return nil
+	}, func() { close(aborted) }, func() { close(done) })
+	if err != context.Canceled {
+		t.Errorf("runCtx cancel: %v", err)
+	}
+	select {
+	case <-aborted:
+	default:
+		t.Error("runCtx cancel: abort not called")
+	}
+	select {
+	case <-done:
+		t.Error("runCtx cancel: done called before f returned")
+	default:
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("runCtx cancel: done not called after f returned")
+	}
+
+	// 未取消时返回f的错误
+	errTest := errors.New("test")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = runCtx(ctx, func() error { return errTest }, func() {}, func() {}); err != errTest {
+		t.Errorf("runCtx error: %v", err)
+	}
+	if err = runCtx(context.Background(), func() error { return errTest }, func() {}, func() {}); err != errTest {
+		t.Errorf("runCtx without Done: %v", err)
+	}
+
+	// panic在调用方goroutine中重新抛出
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("runCtx panic: %v", r)
+			}
+		}()
+		runCtx(ctx, func() error { panic("boom") }, func() {}, func() {})
+		t.Error("runCtx panic: expected panic")
+	}()
+}
+
+func TestMaxTimeOf(t *testing.T) {
+	if mt := maxTimeOf(context.Background()); mt != 0 {
+		t.Errorf("maxTimeOf without deadline: %v", mt)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if mt := maxTimeOf(ctx); mt <= time.Second || mt > 2*time.Second {
+		t.Errorf("maxTimeOf deadline: %v", mt)
+	}
+	if mt := maxTimeOf(WithOptions(ctx, WithMaxTime(500*time.Millisecond))); mt != 500*time.Millisecond {
+		t.Errorf("maxTimeOf WithMaxTime: %v", mt)
+	}
+	if mt := maxTimeOf(WithOptions(ctx, WithMaxTime(time.Minute))); mt > 2*time.Second {
+		t.Errorf("maxTimeOf WithMaxTime greater than deadline: %v", mt)
+	}
+	expired, cancel2 := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel2()
+	if mt := maxTimeOf(expired); mt != time.Millisecond {
+		t.Errorf("maxTimeOf expired: %v", mt)
+	}
+}
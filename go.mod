@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
 	github.com/obase/conf v1.8.0
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,134 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globalsign/mgo"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthCheckTimeout HealthCheck及HealthHandler探测单个实例的超时
+var HealthCheckTimeout = 5 * time.Second
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// Health 单个实例的探测结果. 内存实现没有副本集及版本信息
+type Health struct {
+	Client     string        `json:"client"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	Latency    time.Duration `json:"-"`                    // Ping往返时间
+	LatencyMS  float64       `json:"latencyMs"`            // Latency的毫秒数
+	Version    string        `json:"version,omitempty"`    // 服务器版本
+	ReplicaSet string        `json:"replicaSet,omitempty"` // 副本集名称, 非副本集为空
+	Me         string        `json:"me,omitempty"`         // 当前连接的成员
+	Primary    string        `json:"primary,omitempty"`
+	Secondary  bool          `json:"secondary,omitempty"` // 当前连接的成员是否为secondary
+	Hosts      []string      `json:"hosts,omitempty"`     // 副本集中可选为primary的成员
+}
+
+// isMasterResult isMaster命令的返回
+type isMasterResult struct {
+	IsMaster  bool     `bson:"ismaster"`
+	Secondary bool     `bson:"secondary"`
+	SetName   string   `bson:"setName"`
+	Primary   string   `bson:"primary"`
+	Me        string   `bson:"me"`
+	Hosts     []string `bson:"hosts"`
+	Version   string   `bson:"-"` // 取自buildInfo
+}
+
+// HealthCheck 并发探测Clients中的所有实例, 以多个key注册的实例只探测一次, client为逗号连接的key, 按client排序返回
+func HealthCheck() []*Health {
+	return healthCheck(context.Background())
+}
+
+func healthCheck(ctx context.Context) []*Health {
+	names := make(map[Mongo][]string)
+	for k, m := range defaultRegistry.snapshot() {
+		names[m] = append(names[m], k)
+	}
+	labels := make([]string, 0, len(names))
+	clients := make(map[string]Mongo, len(names))
+	for m, keys := range names {
+		sort.Strings(keys)
+		label := clientLabel(keys)
+		labels = append(labels, label)
+		clients[label] = m
+	}
+	sort.Strings(labels)
+
+	ret := make([]*Health, len(labels))
+	var wg sync.WaitGroup
+	for i, k := range labels {
+		wg.Add(1)
+		go func(i int, k string, m Mongo) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+			defer cancel()
+			ret[i] = probe(ctx, k, m)
+		}(i, k, clients[k])
+	}
+	wg.Wait()
+	return ret
+}
+
+func probe(ctx context.Context, client string, m Mongo) *Health {
+	h := &Health{Client: client, Status: HealthUp}
+	start := time.Now()
+	err := m.Ping(ctx)
+	h.Latency = time.Since(start)
+	h.LatencyMS = float64(h.Latency) / float64(time.Millisecond)
+	if err != nil {
+		h.Status, h.Error = HealthDown, err.Error()
+		return h
+	}
+
+	// 超时返回后f可能仍在执行, 因此通过返回值而不是直接修改h
+	ret, err := m.RunSessionCtx(ctx, func(se *mgo.Session, args ...interface{}) (interface{}, error) {
+		im := new(isMasterResult)
+		if err := se.Run("isMaster", im); err != nil {
+			return nil, err
+		}
+		bi, err := se.BuildInfo()
+		if err != nil {
+			return nil, err
+		}
+		im.Version = bi.Version
+		return im, nil
+	})
+	if err == nil {
+		im := ret.(*isMasterResult)
+		h.Version, h.ReplicaSet, h.Me, h.Primary, h.Secondary, h.Hosts = im.Version, im.SetName, im.Me, im.Primary, im.Secondary, im.Hosts
+	} else if err != ErrNotSupported {
+		h.Status, h.Error = HealthDown, err.Error()
+	}
+	return h
+}
+
+// HealthHandler 以JSON输出HealthCheck结果, 全部实例正常时返回200, 否则返回503
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs := healthCheck(r.Context())
+		status := HealthUp
+		for _, h := range hs {
+			if h.Status != HealthUp {
+				status = HealthDown
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if status != HealthUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(struct {
+			Status  string    `json:"status"`
+			Clients []*Health `json:"clients"`
+		}{status, hs})
+	})
+}
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	down := func(ctx context.Context, op *Operation, next Invoker) error {
+		return io.EOF
+	}
+	if err := RegisterClient("health_down,health_down2", newInterceptMongo(&Config{}, newMemoryMongo(&Config{}), []Interceptor{down}), false); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterClient("health_down")
+	defer UnregisterClient("health_down2")
+
+	if err := Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	var ret struct {
+		Status  string
+		Clients []*Health
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&ret); err != nil {
+		t.Fatal(err)
+	}
+	// 同一实例只探测一次
+	instances := make(map[Mongo]bool)
+	for _, m := range defaultRegistry.snapshot() {
+		instances[m] = true
+	}
+	if rec.Code != 503 || ret.Status != HealthDown || len(ret.Clients) != len(instances) {
+		t.Fatalf("HealthHandler: %d %+v", rec.Code, ret)
+	}
+	for _, h := range ret.Clients {
+		if h.Client == "health_down,health_down2" {
+			if h.Status != HealthDown || h.Error != "EOF" {
+				t.Errorf("down client: %+v", h)
+			}
+		} else if h.Status != HealthUp || h.Error != "" {
+			t.Errorf("up client: %+v", h)
+		}
+	}
+}
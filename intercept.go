@@ -0,0 +1,1016 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"reflect"
+)
+
+/*
+Operation 拦截器看到的操作描述. 各方法统一以DBXxxCtx形式调用拦截链,
+例如FindPage与DBFindPageCtx的Name均为"FindPage". 拦截器修改Operation不影响实际执行的参数
+*/
+type Operation struct {
+	Name       string      // 方法名, 不含DB前缀及Ctx后缀
+	Database   string      // RunSession, ListDatabases为空
+	Collection string      // RunSession及数据库级操作为空
+	Query      interface{} // query, selector, id或pipeline
+	Update     interface{} // update, upsert或Insert的docs
+	Options    OpOptions
+
+	Matched int // 执行成功后填充: 返回或影响的文档数, upsert为1, 失败及不适用的操作为0
+}
+
+// OpOptions 操作的附加参数, 未使用的字段为零值
+type OpOptions struct {
+	Projection interface{}
+	Sort       []string
+	Skip       uint32
+	Limit      uint32
+	Batch      int
+	Key        string              // Distinct的key
+	Token      string              // Keyset的token
+	Index      interface{}         // EnsureIndex的mgo.Index, EnsureIndexKey/DropIndex的key, DropIndexName的name
+	Pipe       *PipeOptions        // Aggregate的选项
+	Count      *CountOptions       // CountWhere的选项
+	Info       *mgo.CollectionInfo // CreateCollection的选项
+	Target     string              // RenameCollection的新集合名
+	Args       []interface{}       // RunBulk/RunCollection/RunSession的args
+}
+
+// Invoker 执行拦截链的下一环
+type Invoker func(ctx context.Context, op *Operation) error
+
+// Interceptor 拦截器, 必须调用next才会执行实际操作; 可多次调用next(如重试)或直接返回错误(如鉴权)
+type Interceptor func(ctx context.Context, op *Operation, next Invoker) error
+
+// interceptMongo 在任意Mongo实现外包装拦截链, 第一个拦截器在最外层
+type interceptMongo struct {
+	*Config
+	next         Mongo
+	interceptors []Interceptor
+}
+
+func newInterceptMongo(opt *Config, next Mongo, interceptors []Interceptor) *interceptMongo {
+	return &interceptMongo{
+		Config:       opt,
+		next:         next,
+		interceptors: interceptors,
+	}
+}
+
+// invoke 依次执行拦截器, f为实际操作, 负责填充op.Matched
+func (im *interceptMongo) invoke(ctx context.Context, op *Operation, f Invoker) error {
+	return im.chain(0, f)(ctx, op)
+}
+
+func (im *interceptMongo) chain(i int, f Invoker) Invoker {
+	if i == len(im.interceptors) {
+		return f
+	}
+	return func(ctx context.Context, op *Operation) error {
+		return im.interceptors[i](ctx, op, im.chain(i+1, f))
+	}
+}
+
+func boolCount(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// resultLen ret指向slice时返回其长度, 否则为0
+func resultLen(ret interface{}) int {
+	v := reflect.ValueOf(ret)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		return v.Len()
+	}
+	return 0
+}
+
+// interceptedIter 拦截器拒绝执行或返回错误时, 释放已创建的迭代器
+func interceptedIter(it Iter, err error) Iter {
+	if err == nil {
+		return it
+	}
+	if it != nil {
+		it.Close()
+	}
+	return &errIter{err: err}
+}
+
+func (im *interceptMongo) Count(c string) (n int, err error) {
+	return im.DBCount(im.Config.Database, c)
+}
+func (im *interceptMongo) CountWhere(c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return im.DBCountWhere(im.Config.Database, c, query, opts...)
+}
+func (im *interceptMongo) EstimatedCount(c string) (n int, err error) {
+	return im.DBEstimatedCount(im.Config.Database, c)
+}
+func (im *interceptMongo) Exists(c string, query interface{}) (ok bool, err error) {
+	return im.DBExists(im.Config.Database, c, query)
+}
+func (im *interceptMongo) Indexes(c string) (indexes []mgo.Index, err error) {
+	return im.DBIndexes(im.Config.Database, c)
+}
+func (im *interceptMongo) EnsureIndex(c string, index mgo.Index) (err error) {
+	return im.DBEnsureIndex(im.Config.Database, c, index)
+}
+func (im *interceptMongo) EnsureIndexKey(c string, key ...string) (err error) {
+	return im.DBEnsureIndexKey(im.Config.Database, c, key...)
+}
+func (im *interceptMongo) DropIndex(c string, key ...string) error {
+	return im.DBDropIndex(im.Config.Database, c, key...)
+}
+func (im *interceptMongo) DropIndexName(c string, name string) error {
+	return im.DBDropIndexName(im.Config.Database, c, name)
+}
+func (im *interceptMongo) CreateCollection(c string, info *mgo.CollectionInfo) error {
+	return im.DBCreateCollection(im.Config.Database, c, info)
+}
+func (im *interceptMongo) DropCollection(c string) error {
+	return im.DBDropCollection(im.Config.Database, c)
+}
+func (im *interceptMongo) RenameCollection(c string, to string, dropTarget bool) error {
+	return im.DBRenameCollection(im.Config.Database, c, to, dropTarget)
+}
+func (im *interceptMongo) CollectionStats(c string) (stats *CollStats, err error) {
+	return im.DBCollectionStats(im.Config.Database, c)
+}
+func (im *interceptMongo) ListCollections() (specs []CollectionSpec, err error) {
+	return im.DBListCollections(im.Config.Database)
+}
+func (im *interceptMongo) DropDatabase() error {
+	return im.DBDropDatabase(im.Config.Database)
+}
+func (im *interceptMongo) DatabaseStats() (stats *DBStats, err error) {
+	return im.DBDatabaseStats(im.Config.Database)
+}
+func (im *interceptMongo) ListDatabases() (specs []DatabaseSpec, err error) {
+	return im.ListDatabasesCtx(context.Background())
+}
+
+func (im *interceptMongo) FindOne(c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return im.DBFindOne(im.Config.Database, c, ret, query)
+}
+func (im *interceptMongo) FindAll(c string, ret interface{}, query interface{}, sort ...string) error {
+	return im.DBFindAll(im.Config.Database, c, ret, query, sort...)
+}
+func (im *interceptMongo) FindRange(c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindRange(im.Config.Database, c, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) FindPage(c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindPage(im.Config.Database, c, tot, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) FindDistinct(c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return im.DBFindDistinct(im.Config.Database, c, ret, query, key, sort...)
+}
+func (im *interceptMongo) FindId(c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return im.DBFindId(im.Config.Database, c, ret, id)
+}
+
+func (im *interceptMongo) SelectOne(c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectOne(im.Config.Database, c, ret, query, projection)
+}
+func (im *interceptMongo) SelectAll(c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return im.DBSelectAll(im.Config.Database, c, ret, query, projection, sort...)
+}
+func (im *interceptMongo) SelectRange(c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectRange(im.Config.Database, c, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) SelectPage(c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectPage(im.Config.Database, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) SelectDistinct(c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return im.DBSelectDistinct(im.Config.Database, c, ret, query, projection, key, sort...)
+}
+func (im *interceptMongo) SelectId(c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectId(im.Config.Database, c, ret, id, projection)
+}
+
+func (im *interceptMongo) Aggregate(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return im.DBAggregate(im.Config.Database, c, ret, pipeline, opts...)
+}
+func (im *interceptMongo) AggregateOne(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return im.DBAggregateOne(im.Config.Database, c, ret, pipeline, opts...)
+}
+
+func (im *interceptMongo) FindIter(c string, query interface{}, batch int, sort ...string) Iter {
+	return im.DBFindIter(im.Config.Database, c, query, batch, sort...)
+}
+func (im *interceptMongo) SelectIter(c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return im.DBSelectIter(im.Config.Database, c, query, projection, batch, sort...)
+}
+
+func (im *interceptMongo) FindKeyset(c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBFindKeyset(im.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (im *interceptMongo) SelectKeyset(c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBSelectKeyset(im.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
+
+func (im *interceptMongo) FindAndUpdate(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdate(im.Config.Database, c, ret, query, update)
+}
+func (im *interceptMongo) FindAndUpsert(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsert(im.Config.Database, c, ret, query, upsert)
+}
+func (im *interceptMongo) FindAndRemove(c string, ret interface{}, query interface{}) (removed int, err error) {
+	return im.DBFindAndRemove(im.Config.Database, c, ret, query)
+}
+func (im *interceptMongo) FindAndUpdateRN(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdateRN(im.Config.Database, c, ret, query, update)
+}
+func (im *interceptMongo) FindAndUpsertRN(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsertRN(im.Config.Database, c, ret, query, upsert)
+}
+
+func (im *interceptMongo) Insert(c string, docs ...interface{}) (err error) {
+	return im.DBInsert(im.Config.Database, c, docs...)
+}
+func (im *interceptMongo) RemoveOne(c string, selector interface{}) (ok bool, err error) {
+	return im.DBRemoveOne(im.Config.Database, c, selector)
+}
+func (im *interceptMongo) RemoveAll(c string, selector interface{}) (removed int, err error) {
+	return im.DBRemoveAll(im.Config.Database, c, selector)
+}
+func (im *interceptMongo) RemoveId(c string, id interface{}) (ok bool, err error) {
+	return im.DBRemoveId(im.Config.Database, c, id)
+}
+func (im *interceptMongo) UpdateOne(c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateOne(im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpdateAll(c string, selector interface{}, update interface{}) (updated int, err error) {
+	return im.DBUpdateAll(im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpdateId(c string, id interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateId(im.Config.Database, c, id, update)
+}
+func (im *interceptMongo) UpsertOne(c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertOne(im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpsertId(c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertId(im.Config.Database, c, id, update)
+}
+func (im *interceptMongo) RunBulk(c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return im.DBRunBulk(im.Config.Database, c, f, args...)
+}
+func (im *interceptMongo) RunCollection(c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return im.DBRunCollection(im.Config.Database, c, f, args...)
+}
+
+func (im *interceptMongo) DBCount(d string, c string) (n int, err error) {
+	return im.DBCountCtx(context.Background(), d, c)
+}
+func (im *interceptMongo) DBCountWhere(d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return im.DBCountWhereCtx(context.Background(), d, c, query, opts...)
+}
+func (im *interceptMongo) DBEstimatedCount(d string, c string) (n int, err error) {
+	return im.DBEstimatedCountCtx(context.Background(), d, c)
+}
+func (im *interceptMongo) DBExists(d string, c string, query interface{}) (ok bool, err error) {
+	return im.DBExistsCtx(context.Background(), d, c, query)
+}
+func (im *interceptMongo) DBIndexes(d string, c string) (indexes []mgo.Index, err error) {
+	return im.DBIndexesCtx(context.Background(), d, c)
+}
+func (im *interceptMongo) DBEnsureIndex(d string, c string, index mgo.Index) (err error) {
+	return im.DBEnsureIndexCtx(context.Background(), d, c, index)
+}
+func (im *interceptMongo) DBEnsureIndexKey(d string, c string, key ...string) (err error) {
+	return im.DBEnsureIndexKeyCtx(context.Background(), d, c, key...)
+}
+func (im *interceptMongo) DBDropIndex(d string, c string, key ...string) error {
+	return im.DBDropIndexCtx(context.Background(), d, c, key...)
+}
+func (im *interceptMongo) DBDropIndexName(d string, c string, name string) error {
+	return im.DBDropIndexNameCtx(context.Background(), d, c, name)
+}
+func (im *interceptMongo) DBCreateCollection(d string, c string, info *mgo.CollectionInfo) error {
+	return im.DBCreateCollectionCtx(context.Background(), d, c, info)
+}
+func (im *interceptMongo) DBDropCollection(d string, c string) error {
+	return im.DBDropCollectionCtx(context.Background(), d, c)
+}
+func (im *interceptMongo) DBRenameCollection(d string, c string, to string, dropTarget bool) error {
+	return im.DBRenameCollectionCtx(context.Background(), d, c, to, dropTarget)
+}
+func (im *interceptMongo) DBCollectionStats(d string, c string) (stats *CollStats, err error) {
+	return im.DBCollectionStatsCtx(context.Background(), d, c)
+}
+func (im *interceptMongo) DBListCollections(d string) (specs []CollectionSpec, err error) {
+	return im.DBListCollectionsCtx(context.Background(), d)
+}
+func (im *interceptMongo) DBDropDatabase(d string) error {
+	return im.DBDropDatabaseCtx(context.Background(), d)
+}
+func (im *interceptMongo) DBDatabaseStats(d string) (stats *DBStats, err error) {
+	return im.DBDatabaseStatsCtx(context.Background(), d)
+}
+
+func (im *interceptMongo) DBFindOne(d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return im.DBFindOneCtx(context.Background(), d, c, ret, query)
+}
+func (im *interceptMongo) DBFindAll(d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return im.DBFindAllCtx(context.Background(), d, c, ret, query, sort...)
+}
+func (im *interceptMongo) DBFindRange(d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindRangeCtx(context.Background(), d, c, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) DBFindPage(d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindPageCtx(context.Background(), d, c, tot, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) DBFindDistinct(d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return im.DBFindDistinctCtx(context.Background(), d, c, ret, query, key, sort...)
+}
+func (im *interceptMongo) DBFindId(d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return im.DBFindIdCtx(context.Background(), d, c, ret, id)
+}
+
+func (im *interceptMongo) DBSelectOne(d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectOneCtx(context.Background(), d, c, ret, query, projection)
+}
+func (im *interceptMongo) DBSelectAll(d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return im.DBSelectAllCtx(context.Background(), d, c, ret, query, projection, sort...)
+}
+func (im *interceptMongo) DBSelectRange(d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectRangeCtx(context.Background(), d, c, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) DBSelectPage(d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectPageCtx(context.Background(), d, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) DBSelectDistinct(d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return im.DBSelectDistinctCtx(context.Background(), d, c, ret, query, projection, key, sort...)
+}
+func (im *interceptMongo) DBSelectId(d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectIdCtx(context.Background(), d, c, ret, id, projection)
+}
+
+func (im *interceptMongo) DBAggregate(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return im.DBAggregateCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+func (im *interceptMongo) DBAggregateOne(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return im.DBAggregateOneCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+
+func (im *interceptMongo) DBFindIter(d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return im.DBFindIterCtx(context.Background(), d, c, query, batch, sort...)
+}
+func (im *interceptMongo) DBSelectIter(d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return im.DBSelectIterCtx(context.Background(), d, c, query, projection, batch, sort...)
+}
+
+func (im *interceptMongo) DBFindKeyset(d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBFindKeysetCtx(context.Background(), d, c, ret, query, token, limit, sort...)
+}
+func (im *interceptMongo) DBSelectKeyset(d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBSelectKeysetCtx(context.Background(), d, c, ret, query, projection, token, limit, sort...)
+}
+
+func (im *interceptMongo) DBFindAndUpdate(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdateCtx(context.Background(), d, c, ret, query, update)
+}
+func (im *interceptMongo) DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsertCtx(context.Background(), d, c, ret, query, upsert)
+}
+func (im *interceptMongo) DBFindAndRemove(d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return im.DBFindAndRemoveCtx(context.Background(), d, c, ret, query)
+}
+func (im *interceptMongo) DBFindAndUpdateRN(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdateRNCtx(context.Background(), d, c, ret, query, update)
+}
+func (im *interceptMongo) DBFindAndUpsertRN(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsertRNCtx(context.Background(), d, c, ret, query, upsert)
+}
+
+func (im *interceptMongo) DBInsert(d string, c string, docs ...interface{}) (err error) {
+	return im.DBInsertCtx(context.Background(), d, c, docs...)
+}
+func (im *interceptMongo) DBRemoveOne(d string, c string, selector interface{}) (ok bool, err error) {
+	return im.DBRemoveOneCtx(context.Background(), d, c, selector)
+}
+func (im *interceptMongo) DBRemoveAll(d string, c string, selector interface{}) (removed int, err error) {
+	return im.DBRemoveAllCtx(context.Background(), d, c, selector)
+}
+func (im *interceptMongo) DBRemoveId(d string, c string, id interface{}) (ok bool, err error) {
+	return im.DBRemoveIdCtx(context.Background(), d, c, id)
+}
+func (im *interceptMongo) DBUpdateOne(d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateOneCtx(context.Background(), d, c, selector, update)
+}
+func (im *interceptMongo) DBUpdateAll(d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return im.DBUpdateAllCtx(context.Background(), d, c, selector, update)
+}
+func (im *interceptMongo) DBUpdateId(d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateIdCtx(context.Background(), d, c, id, update)
+}
+func (im *interceptMongo) DBUpsertOne(d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertOneCtx(context.Background(), d, c, selector, update)
+}
+func (im *interceptMongo) DBUpsertId(d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertIdCtx(context.Background(), d, c, id, update)
+}
+func (im *interceptMongo) DBRunBulk(d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return im.DBRunBulkCtx(context.Background(), d, c, f, args...)
+}
+func (im *interceptMongo) DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return im.DBRunCollectionCtx(context.Background(), d, c, f, args...)
+}
+
+func (im *interceptMongo) RunSession(f SessionFunc, args ...interface{}) (interface{}, error) {
+	return im.RunSessionCtx(context.Background(), f, args...)
+}
+
+func (im *interceptMongo) CountCtx(ctx context.Context, c string) (n int, err error) {
+	return im.DBCountCtx(ctx, im.Config.Database, c)
+}
+func (im *interceptMongo) CountWhereCtx(ctx context.Context, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return im.DBCountWhereCtx(ctx, im.Config.Database, c, query, opts...)
+}
+func (im *interceptMongo) EstimatedCountCtx(ctx context.Context, c string) (n int, err error) {
+	return im.DBEstimatedCountCtx(ctx, im.Config.Database, c)
+}
+func (im *interceptMongo) ExistsCtx(ctx context.Context, c string, query interface{}) (ok bool, err error) {
+	return im.DBExistsCtx(ctx, im.Config.Database, c, query)
+}
+func (im *interceptMongo) IndexesCtx(ctx context.Context, c string) (indexes []mgo.Index, err error) {
+	return im.DBIndexesCtx(ctx, im.Config.Database, c)
+}
+func (im *interceptMongo) EnsureIndexCtx(ctx context.Context, c string, index mgo.Index) (err error) {
+	return im.DBEnsureIndexCtx(ctx, im.Config.Database, c, index)
+}
+func (im *interceptMongo) EnsureIndexKeyCtx(ctx context.Context, c string, key ...string) (err error) {
+	return im.DBEnsureIndexKeyCtx(ctx, im.Config.Database, c, key...)
+}
+func (im *interceptMongo) DropIndexCtx(ctx context.Context, c string, key ...string) error {
+	return im.DBDropIndexCtx(ctx, im.Config.Database, c, key...)
+}
+func (im *interceptMongo) DropIndexNameCtx(ctx context.Context, c string, name string) error {
+	return im.DBDropIndexNameCtx(ctx, im.Config.Database, c, name)
+}
+func (im *interceptMongo) CreateCollectionCtx(ctx context.Context, c string, info *mgo.CollectionInfo) error {
+	return im.DBCreateCollectionCtx(ctx, im.Config.Database, c, info)
+}
+func (im *interceptMongo) DropCollectionCtx(ctx context.Context, c string) error {
+	return im.DBDropCollectionCtx(ctx, im.Config.Database, c)
+}
+func (im *interceptMongo) RenameCollectionCtx(ctx context.Context, c string, to string, dropTarget bool) error {
+	return im.DBRenameCollectionCtx(ctx, im.Config.Database, c, to, dropTarget)
+}
+func (im *interceptMongo) CollectionStatsCtx(ctx context.Context, c string) (stats *CollStats, err error) {
+	return im.DBCollectionStatsCtx(ctx, im.Config.Database, c)
+}
+func (im *interceptMongo) ListCollectionsCtx(ctx context.Context) (specs []CollectionSpec, err error) {
+	return im.DBListCollectionsCtx(ctx, im.Config.Database)
+}
+func (im *interceptMongo) DropDatabaseCtx(ctx context.Context) error {
+	return im.DBDropDatabaseCtx(ctx, im.Config.Database)
+}
+func (im *interceptMongo) DatabaseStatsCtx(ctx context.Context) (stats *DBStats, err error) {
+	return im.DBDatabaseStatsCtx(ctx, im.Config.Database)
+}
+func (im *interceptMongo) ListDatabasesCtx(ctx context.Context) (specs []DatabaseSpec, err error) {
+	err = im.invoke(ctx, &Operation{Name: "ListDatabases"}, func(ctx context.Context, op *Operation) (err error) {
+		specs, err = im.next.ListDatabasesCtx(ctx)
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) FindOneCtx(ctx context.Context, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return im.DBFindOneCtx(ctx, im.Config.Database, c, ret, query)
+}
+func (im *interceptMongo) FindAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, sort ...string) error {
+	return im.DBFindAllCtx(ctx, im.Config.Database, c, ret, query, sort...)
+}
+func (im *interceptMongo) FindRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindRangeCtx(ctx, im.Config.Database, c, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) FindPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBFindPageCtx(ctx, im.Config.Database, c, tot, ret, query, skip, limit, sort...)
+}
+func (im *interceptMongo) FindDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return im.DBFindDistinctCtx(ctx, im.Config.Database, c, ret, query, key, sort...)
+}
+func (im *interceptMongo) FindIdCtx(ctx context.Context, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return im.DBFindIdCtx(ctx, im.Config.Database, c, ret, id)
+}
+
+func (im *interceptMongo) SelectOneCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectOneCtx(ctx, im.Config.Database, c, ret, query, projection)
+}
+func (im *interceptMongo) SelectAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return im.DBSelectAllCtx(ctx, im.Config.Database, c, ret, query, projection, sort...)
+}
+func (im *interceptMongo) SelectRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectRangeCtx(ctx, im.Config.Database, c, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) SelectPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.DBSelectPageCtx(ctx, im.Config.Database, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (im *interceptMongo) SelectDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return im.DBSelectDistinctCtx(ctx, im.Config.Database, c, ret, query, projection, key, sort...)
+}
+func (im *interceptMongo) SelectIdCtx(ctx context.Context, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return im.DBSelectIdCtx(ctx, im.Config.Database, c, ret, id, projection)
+}
+
+func (im *interceptMongo) AggregateCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return im.DBAggregateCtx(ctx, im.Config.Database, c, ret, pipeline, opts...)
+}
+func (im *interceptMongo) AggregateOneCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return im.DBAggregateOneCtx(ctx, im.Config.Database, c, ret, pipeline, opts...)
+}
+
+func (im *interceptMongo) FindIterCtx(ctx context.Context, c string, query interface{}, batch int, sort ...string) Iter {
+	return im.DBFindIterCtx(ctx, im.Config.Database, c, query, batch, sort...)
+}
+func (im *interceptMongo) SelectIterCtx(ctx context.Context, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return im.DBSelectIterCtx(ctx, im.Config.Database, c, query, projection, batch, sort...)
+}
+
+func (im *interceptMongo) FindKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBFindKeysetCtx(ctx, im.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (im *interceptMongo) SelectKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return im.DBSelectKeysetCtx(ctx, im.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
+
+func (im *interceptMongo) FindAndUpdateCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdateCtx(ctx, im.Config.Database, c, ret, query, update)
+}
+func (im *interceptMongo) FindAndUpsertCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsertCtx(ctx, im.Config.Database, c, ret, query, upsert)
+}
+func (im *interceptMongo) FindAndRemoveCtx(ctx context.Context, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return im.DBFindAndRemoveCtx(ctx, im.Config.Database, c, ret, query)
+}
+func (im *interceptMongo) FindAndUpdateRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return im.DBFindAndUpdateRNCtx(ctx, im.Config.Database, c, ret, query, update)
+}
+func (im *interceptMongo) FindAndUpsertRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return im.DBFindAndUpsertRNCtx(ctx, im.Config.Database, c, ret, query, upsert)
+}
+
+func (im *interceptMongo) InsertCtx(ctx context.Context, c string, docs ...interface{}) (err error) {
+	return im.DBInsertCtx(ctx, im.Config.Database, c, docs...)
+}
+func (im *interceptMongo) RemoveOneCtx(ctx context.Context, c string, selector interface{}) (ok bool, err error) {
+	return im.DBRemoveOneCtx(ctx, im.Config.Database, c, selector)
+}
+func (im *interceptMongo) RemoveAllCtx(ctx context.Context, c string, selector interface{}) (removed int, err error) {
+	return im.DBRemoveAllCtx(ctx, im.Config.Database, c, selector)
+}
+func (im *interceptMongo) RemoveIdCtx(ctx context.Context, c string, id interface{}) (ok bool, err error) {
+	return im.DBRemoveIdCtx(ctx, im.Config.Database, c, id)
+}
+func (im *interceptMongo) UpdateOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateOneCtx(ctx, im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpdateAllCtx(ctx context.Context, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return im.DBUpdateAllCtx(ctx, im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpdateIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return im.DBUpdateIdCtx(ctx, im.Config.Database, c, id, update)
+}
+func (im *interceptMongo) UpsertOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertOneCtx(ctx, im.Config.Database, c, selector, update)
+}
+func (im *interceptMongo) UpsertIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return im.DBUpsertIdCtx(ctx, im.Config.Database, c, id, update)
+}
+func (im *interceptMongo) RunBulkCtx(ctx context.Context, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return im.DBRunBulkCtx(ctx, im.Config.Database, c, f, args...)
+}
+func (im *interceptMongo) RunCollectionCtx(ctx context.Context, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return im.DBRunCollectionCtx(ctx, im.Config.Database, c, f, args...)
+}
+
+func (im *interceptMongo) DBCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "Count", Database: d, Collection: c}, func(ctx context.Context, op *Operation) (err error) {
+		if n, err = im.next.DBCountCtx(ctx, d, c); err == nil {
+			op.Matched = n
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBCountWhereCtx(ctx context.Context, d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "CountWhere", Database: d, Collection: c, Query: query, Options: OpOptions{Count: countOptions(opts)}}, func(ctx context.Context, op *Operation) (err error) {
+		if n, err = im.next.DBCountWhereCtx(ctx, d, c, query, opts...); err == nil {
+			op.Matched = n
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBEstimatedCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "EstimatedCount", Database: d, Collection: c}, func(ctx context.Context, op *Operation) (err error) {
+		if n, err = im.next.DBEstimatedCountCtx(ctx, d, c); err == nil {
+			op.Matched = n
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBExistsCtx(ctx context.Context, d string, c string, query interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "Exists", Database: d, Collection: c, Query: query}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBExistsCtx(ctx, d, c, query); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBIndexesCtx(ctx context.Context, d string, c string) (indexes []mgo.Index, err error) {
+	err = im.invoke(ctx, &Operation{Name: "Indexes", Database: d, Collection: c}, func(ctx context.Context, op *Operation) (err error) {
+		indexes, err = im.next.DBIndexesCtx(ctx, d, c)
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBEnsureIndexCtx(ctx context.Context, d string, c string, index mgo.Index) error {
+	return im.invoke(ctx, &Operation{Name: "EnsureIndex", Database: d, Collection: c, Options: OpOptions{Index: index}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBEnsureIndexCtx(ctx, d, c, index)
+	})
+}
+func (im *interceptMongo) DBEnsureIndexKeyCtx(ctx context.Context, d string, c string, key ...string) error {
+	return im.invoke(ctx, &Operation{Name: "EnsureIndexKey", Database: d, Collection: c, Options: OpOptions{Index: key}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBEnsureIndexKeyCtx(ctx, d, c, key...)
+	})
+}
+func (im *interceptMongo) DBDropIndexCtx(ctx context.Context, d string, c string, key ...string) error {
+	return im.invoke(ctx, &Operation{Name: "DropIndex", Database: d, Collection: c, Options: OpOptions{Index: key}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBDropIndexCtx(ctx, d, c, key...)
+	})
+}
+func (im *interceptMongo) DBDropIndexNameCtx(ctx context.Context, d string, c string, name string) error {
+	return im.invoke(ctx, &Operation{Name: "DropIndexName", Database: d, Collection: c, Options: OpOptions{Index: name}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBDropIndexNameCtx(ctx, d, c, name)
+	})
+}
+func (im *interceptMongo) DBCreateCollectionCtx(ctx context.Context, d string, c string, info *mgo.CollectionInfo) error {
+	return im.invoke(ctx, &Operation{Name: "CreateCollection", Database: d, Collection: c, Options: OpOptions{Info: info}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBCreateCollectionCtx(ctx, d, c, info)
+	})
+}
+func (im *interceptMongo) DBDropCollectionCtx(ctx context.Context, d string, c string) error {
+	return im.invoke(ctx, &Operation{Name: "DropCollection", Database: d, Collection: c}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBDropCollectionCtx(ctx, d, c)
+	})
+}
+func (im *interceptMongo) DBRenameCollectionCtx(ctx context.Context, d string, c string, to string, dropTarget bool) error {
+	return im.invoke(ctx, &Operation{Name: "RenameCollection", Database: d, Collection: c, Options: OpOptions{Target: to}}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBRenameCollectionCtx(ctx, d, c, to, dropTarget)
+	})
+}
+func (im *interceptMongo) DBCollectionStatsCtx(ctx context.Context, d string, c string) (stats *CollStats, err error) {
+	err = im.invoke(ctx, &Operation{Name: "CollectionStats", Database: d, Collection: c}, func(ctx context.Context, op *Operation) (err error) {
+		stats, err = im.next.DBCollectionStatsCtx(ctx, d, c)
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBListCollectionsCtx(ctx context.Context, d string) (specs []CollectionSpec, err error) {
+	err = im.invoke(ctx, &Operation{Name: "ListCollections", Database: d}, func(ctx context.Context, op *Operation) (err error) {
+		specs, err = im.next.DBListCollectionsCtx(ctx, d)
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBDropDatabaseCtx(ctx context.Context, d string) error {
+	return im.invoke(ctx, &Operation{Name: "DropDatabase", Database: d}, func(ctx context.Context, op *Operation) error {
+		return im.next.DBDropDatabaseCtx(ctx, d)
+	})
+}
+func (im *interceptMongo) DBDatabaseStatsCtx(ctx context.Context, d string) (stats *DBStats, err error) {
+	err = im.invoke(ctx, &Operation{Name: "DatabaseStats", Database: d}, func(ctx context.Context, op *Operation) (err error) {
+		stats, err = im.next.DBDatabaseStatsCtx(ctx, d)
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBFindOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindOne", Database: d, Collection: c, Query: query}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBFindOneCtx(ctx, d, c, ret, query); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBFindAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "FindAll", Database: d, Collection: c, Query: query, Options: OpOptions{Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBFindAllCtx(ctx, d, c, ret, query, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBFindRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "FindRange", Database: d, Collection: c, Query: query, Options: OpOptions{Skip: skip, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBFindRangeCtx(ctx, d, c, ret, query, skip, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBFindPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "FindPage", Database: d, Collection: c, Query: query, Options: OpOptions{Skip: skip, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBFindPageCtx(ctx, d, c, tot, ret, query, skip, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBFindDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "FindDistinct", Database: d, Collection: c, Query: query, Options: OpOptions{Key: key, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBFindDistinctCtx(ctx, d, c, ret, query, key, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBFindIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindId", Database: d, Collection: c, Query: id}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBFindIdCtx(ctx, d, c, ret, id); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBSelectOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "SelectOne", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection}}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBSelectOneCtx(ctx, d, c, ret, query, projection); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBSelectAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "SelectAll", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBSelectAllCtx(ctx, d, c, ret, query, projection, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBSelectRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "SelectRange", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Skip: skip, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBSelectRangeCtx(ctx, d, c, ret, query, projection, skip, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBSelectPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "SelectPage", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Skip: skip, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBSelectPageCtx(ctx, d, c, tot, ret, query, projection, skip, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBSelectDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return im.invoke(ctx, &Operation{Name: "SelectDistinct", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Key: key, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBSelectDistinctCtx(ctx, d, c, ret, query, projection, key, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBSelectIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "SelectId", Database: d, Collection: c, Query: id, Options: OpOptions{Projection: projection}}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBSelectIdCtx(ctx, d, c, ret, id, projection); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBAggregateCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return im.invoke(ctx, &Operation{Name: "Aggregate", Database: d, Collection: c, Query: pipeline, Options: OpOptions{Pipe: pipeOptions(opts)}}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBAggregateCtx(ctx, d, c, ret, pipeline, opts...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBAggregateOneCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "AggregateOne", Database: d, Collection: c, Query: pipeline, Options: OpOptions{Pipe: pipeOptions(opts)}}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBAggregateOneCtx(ctx, d, c, ret, pipeline, opts...); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBFindIterCtx(ctx context.Context, d string, c string, query interface{}, batch int, sort ...string) Iter {
+	var it Iter
+	err := im.invoke(ctx, &Operation{Name: "FindIter", Database: d, Collection: c, Query: query, Options: OpOptions{Batch: batch, Sort: sort}}, func(ctx context.Context, op *Operation) error {
+		it = im.next.DBFindIterCtx(ctx, d, c, query, batch, sort...)
+		return it.Err()
+	})
+	return interceptedIter(it, err)
+}
+func (im *interceptMongo) DBSelectIterCtx(ctx context.Context, d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	var it Iter
+	err := im.invoke(ctx, &Operation{Name: "SelectIter", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Batch: batch, Sort: sort}}, func(ctx context.Context, op *Operation) error {
+		it = im.next.DBSelectIterCtx(ctx, d, c, query, projection, batch, sort...)
+		return it.Err()
+	})
+	return interceptedIter(it, err)
+}
+
+func (im *interceptMongo) DBFindKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindKeyset", Database: d, Collection: c, Query: query, Options: OpOptions{Token: token, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if next, prev, err = im.next.DBFindKeysetCtx(ctx, d, c, ret, query, token, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBSelectKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	err = im.invoke(ctx, &Operation{Name: "SelectKeyset", Database: d, Collection: c, Query: query, Options: OpOptions{Projection: projection, Token: token, Limit: limit, Sort: sort}}, func(ctx context.Context, op *Operation) (err error) {
+		if next, prev, err = im.next.DBSelectKeysetCtx(ctx, d, c, ret, query, projection, token, limit, sort...); err == nil {
+			op.Matched = resultLen(ret)
+		}
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBFindAndUpdateCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindAndUpdate", Database: d, Collection: c, Query: query, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if updated, err = im.next.DBFindAndUpdateCtx(ctx, d, c, ret, query, update); err == nil {
+			op.Matched = updated
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBFindAndUpsertCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindAndUpsert", Database: d, Collection: c, Query: query, Update: upsert}, func(ctx context.Context, op *Operation) (err error) {
+		if upsertedId, err = im.next.DBFindAndUpsertCtx(ctx, d, c, ret, query, upsert); err == nil {
+			op.Matched = 1
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBFindAndRemoveCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindAndRemove", Database: d, Collection: c, Query: query}, func(ctx context.Context, op *Operation) (err error) {
+		if removed, err = im.next.DBFindAndRemoveCtx(ctx, d, c, ret, query); err == nil {
+			op.Matched = removed
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBFindAndUpdateRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindAndUpdateRN", Database: d, Collection: c, Query: query, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if updated, err = im.next.DBFindAndUpdateRNCtx(ctx, d, c, ret, query, update); err == nil {
+			op.Matched = updated
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBFindAndUpsertRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "FindAndUpsertRN", Database: d, Collection: c, Query: query, Update: upsert}, func(ctx context.Context, op *Operation) (err error) {
+		if upsertedId, err = im.next.DBFindAndUpsertRNCtx(ctx, d, c, ret, query, upsert); err == nil {
+			op.Matched = 1
+		}
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) DBInsertCtx(ctx context.Context, d string, c string, docs ...interface{}) error {
+	return im.invoke(ctx, &Operation{Name: "Insert", Database: d, Collection: c, Update: docs}, func(ctx context.Context, op *Operation) (err error) {
+		if err = im.next.DBInsertCtx(ctx, d, c, docs...); err == nil {
+			op.Matched = len(docs)
+		}
+		return
+	})
+}
+func (im *interceptMongo) DBRemoveOneCtx(ctx context.Context, d string, c string, selector interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RemoveOne", Database: d, Collection: c, Query: selector}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBRemoveOneCtx(ctx, d, c, selector); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBRemoveAllCtx(ctx context.Context, d string, c string, selector interface{}) (removed int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RemoveAll", Database: d, Collection: c, Query: selector}, func(ctx context.Context, op *Operation) (err error) {
+		if removed, err = im.next.DBRemoveAllCtx(ctx, d, c, selector); err == nil {
+			op.Matched = removed
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBRemoveIdCtx(ctx context.Context, d string, c string, id interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RemoveId", Database: d, Collection: c, Query: id}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBRemoveIdCtx(ctx, d, c, id); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBUpdateOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "UpdateOne", Database: d, Collection: c, Query: selector, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBUpdateOneCtx(ctx, d, c, selector, update); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBUpdateAllCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "UpdateAll", Database: d, Collection: c, Query: selector, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if updated, err = im.next.DBUpdateAllCtx(ctx, d, c, selector, update); err == nil {
+			op.Matched = updated
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBUpdateIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	err = im.invoke(ctx, &Operation{Name: "UpdateId", Database: d, Collection: c, Query: id, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if ok, err = im.next.DBUpdateIdCtx(ctx, d, c, id, update); err == nil {
+			op.Matched = boolCount(ok)
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBUpsertOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "UpsertOne", Database: d, Collection: c, Query: selector, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if upsertId, err = im.next.DBUpsertOneCtx(ctx, d, c, selector, update); err == nil {
+			op.Matched = 1
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBUpsertIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "UpsertId", Database: d, Collection: c, Query: id, Update: update}, func(ctx context.Context, op *Operation) (err error) {
+		if upsertId, err = im.next.DBUpsertIdCtx(ctx, d, c, id, update); err == nil {
+			op.Matched = 1
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBRunBulkCtx(ctx context.Context, d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RunBulk", Database: d, Collection: c, Options: OpOptions{Args: args}}, func(ctx context.Context, op *Operation) (err error) {
+		if matched, modified, err = im.next.DBRunBulkCtx(ctx, d, c, f, args...); err == nil {
+			op.Matched = matched
+		}
+		return
+	})
+	return
+}
+func (im *interceptMongo) DBRunCollectionCtx(ctx context.Context, d string, c string, f CollectionFunc, args ...interface{}) (result interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RunCollection", Database: d, Collection: c, Options: OpOptions{Args: args}}, func(ctx context.Context, op *Operation) (err error) {
+		result, err = im.next.DBRunCollectionCtx(ctx, d, c, f, args...)
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) RunSessionCtx(ctx context.Context, f SessionFunc, args ...interface{}) (result interface{}, err error) {
+	err = im.invoke(ctx, &Operation{Name: "RunSession", Options: OpOptions{Args: args}}, func(ctx context.Context, op *Operation) (err error) {
+		result, err = im.next.RunSessionCtx(ctx, f, args...)
+		return
+	})
+	return
+}
+
+func (im *interceptMongo) Ping(ctx context.Context) error {
+	return im.invoke(ctx, &Operation{Name: "Ping"}, func(ctx context.Context, op *Operation) error {
+		return im.next.Ping(ctx)
+	})
+}
+func (im *interceptMongo) Close() error {
+	return im.next.Close()
+}
@@ -0,0 +1,271 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIntercept(t *testing.T) {
+	var ops []Operation
+	record := func(ctx context.Context, op *Operation, next Invoker) error {
+		ops = append(ops, *op)
+		return next(ctx, op)
+	}
+	denied := errors.New("denied")
+	deny := func(ctx context.Context, op *Operation, next Invoker) error {
+		if op.Collection == "secret" {
+			return denied
+		}
+		return next(ctx, op)
+	}
+	opt := &Config{Database: "test", Interceptors: []Interceptor{record}}
+	m := newInterceptMongo(opt, newMemoryFixture(t), []Interceptor{record, deny})
+
+	var us []memUser
+	if err := m.FindRange("user", &us, bson.M{"age": 25}, 1, 1, "_id"); err != nil || !reflect.DeepEqual(ids(us), []int{4}) {
+		t.Fatalf("FindRange: %v %v", ids(us), err)
+	}
+	want := Operation{Name: "FindRange", Database: "test", Collection: "user", Query: bson.M{"age": 25}, Options: OpOptions{Skip: 1, Limit: 1, Sort: []string{"_id"}}}
+	if len(ops) != 1 || !reflect.DeepEqual(ops[0], want) {
+		t.Errorf("Operation: %+v", ops)
+	}
+
+	if _, err := m.DBUpdateIdCtx(context.Background(), "test", "secret", 1, U().Set("a", 1)); err != denied {
+		t.Errorf("UpdateId denied: %v", err)
+	}
+	if it := m.FindIter("secret", nil, 0); it.Next(&us) || it.Close() != denied {
+		t.Error("FindIter denied: expected error")
+	}
+	if _, err := m.RunSession(nil, 1, 2); err != ErrNotSupported {
+		t.Errorf("RunSession: %v", err)
+	}
+	if op := ops[len(ops)-1]; op.Name != "RunSession" || !reflect.DeepEqual(op.Options.Args, []interface{}{1, 2}) {
+		t.Errorf("RunSession operation: %+v", op)
+	}
+
+	// next可以多次调用
+	calls := 0
+	twice := func(ctx context.Context, op *Operation, next Invoker) error {
+		calls++
+		if err := next(ctx, op); err != nil {
+			return err
+		}
+		return next(ctx, op)
+	}
+	m = newInterceptMongo(opt, newMemoryFixture(t), []Interceptor{twice})
+	if n, err := m.UpdateAll("user", bson.M{"_id": bson.M{"$in": []int{2, 4}}}, U().Inc("age", 1)); n != 2 || err != nil || calls != 1 {
+		t.Errorf("UpdateAll: %v %v %v", n, err, calls)
+	}
+	if n, _ := m.Count("user"); n != 4 {
+		t.Errorf("Count: %v", n)
+	}
+	var u memUser
+	if m.FindId("user", &u, 2); u.Age != 27 {
+		t.Errorf("FindId: %+v", u)
+	}
+}
+
+type slowQueryRecorder []*SlowQuery
+
+func (r *slowQueryRecorder) LogSlowQuery(sq *SlowQuery) {
+	*r = append(*r, sq)
+}
+
+func TestSlowQuery(t *testing.T) {
+	var rec slowQueryRecorder
+	opt := &Config{Database: "test", SlowQueryThreshold: time.Nanosecond, RedactFields: []string{"password"}, SlowQueryLogger: &rec}
+	m := newInterceptMongo(opt, newMemoryFixture(t), []Interceptor{slowQueryInterceptor("slow", opt)})
+
+	var us []memUser
+	query := bson.M{"age": 25, "$or": []bson.M{{"password": "secret"}, {"auth.password": bson.M{"$ne": ""}}, {"name": "bob"}}}
+	if err := m.FindRange("user", &us, query, 0, 10, "-ctime"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec) != 1 {
+		t.Fatalf("LogSlowQuery: %d", len(rec))
+	}
+	sq := rec[0]
+	wantQuery := bson.M{"age": 25, "$or": []interface{}{bson.M{"password": "***"}, bson.M{"auth.password": "***"}, bson.M{"name": "bob"}}}
+	if sq.Client != "slow" || sq.Collection != "user" || sq.Operation != "FindRange" || sq.Matched != 2 || sq.Limit != 10 ||
+		!reflect.DeepEqual(sq.Sort, []string{"-ctime"}) || !reflect.DeepEqual(sq.Query, wantQuery) || sq.Duration <= 0 {
+		t.Errorf("SlowQuery: %+v", sq)
+	}
+	if query["$or"].([]bson.M)[0]["password"] != "secret" {
+		t.Error("redactQuery modified the original query")
+	}
+
+	if _, err := m.RemoveId("user", 1); err != nil || rec[1].Query != 1 || rec[1].Matched != 1 {
+		t.Errorf("RemoveId: %v %+v", err, rec[1])
+	}
+	// 只在成功时填充Matched, 单个文档的ret不计
+	var u memUser
+	if _, err := m.FindAndUpsert("user", &u, bson.M{"_id": 9}, U().Set("name", "zed")); err != nil || rec[2].Matched != 1 {
+		t.Errorf("FindAndUpsert: %v %+v", err, rec[2])
+	}
+	if _, err := m.FindAndUpdate("user", &u, bson.M{"_id": 10}, U().Set("name", "zed")); rec[3].Matched != 0 {
+		t.Errorf("FindAndUpdate not found: %v %+v", err, rec[3])
+	}
+	if err := m.Insert("user", bson.M{"_id": 2}); err == nil || rec[4].Matched != 0 {
+		t.Errorf("Insert duplicate: %v %+v", err, rec[4])
+	}
+	if ok, err := m.AggregateOne("user", &u, []bson.M{{"$match": bson.M{"_id": 2}}}); !ok || err != nil || rec[5].Matched != 1 {
+		t.Errorf("AggregateOne: %v %v %+v", ok, err, rec[5])
+	}
+
+	opt.SlowQueryThreshold = time.Hour
+	m = newInterceptMongo(opt, newMemoryFixture(t), []Interceptor{slowQueryInterceptor("slow", opt)})
+	if m.Count("user"); len(rec) != 6 {
+		t.Errorf("LogSlowQuery below threshold: %d", len(rec))
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var fails, calls, refreshes int
+	var failErr error
+	inject := func(ctx context.Context, op *Operation, next Invoker) error {
+		calls++
+		if fails > 0 {
+			fails--
+			return failErr
+		}
+		return next(ctx, op)
+	}
+	opt := &Config{Database: "test"}
+	retry := retryInterceptor(&RetryPolicy{Attempts: 3, Backoff: time.Millisecond, Jitter: 0.5}, func() { refreshes++ })
+	m := newInterceptMongo(opt, newMemoryFixture(t), []Interceptor{retry, inject})
+
+	cases := []struct {
+		name  string
+		err   error
+		fails int
+		f     func() error
+		calls int
+		ok    bool
+	}{
+		{"FindAll EOF", io.EOF, 2, func() error { var us []memUser; return m.FindAll("user", &us, nil) }, 3, true},
+		{"FindAll attempts exceeded", io.EOF, 3, func() error { var us []memUser; return m.FindAll("user", &us, nil) }, 3, false},
+		{"FindId not master", &mgo.QueryError{Code: 10107, Message: "not master"}, 1, func() error { var u memUser; _, err := m.FindId("user", &u, 1); return err }, 2, true},
+		{"FindAll other error", errors.New("bad query"), 1, func() error { var us []memUser; return m.FindAll("user", &us, nil) }, 1, false},
+		{"Insert", io.EOF, 1, func() error { return m.Insert("user", bson.M{"_id": 10}) }, 1, false},
+		{"Insert WithRetry", io.EOF, 1, func() error { return m.InsertCtx(WithRetry(context.Background()), "user", bson.M{"_id": 11}) }, 2, true},
+		{"UpdateId $set", io.EOF, 1, func() error { _, err := m.UpdateId("user", 1, U().Set("age", 40)); return err }, 2, true},
+		{"UpdateId $inc", io.EOF, 1, func() error { _, err := m.UpdateId("user", 1, U().Inc("age", 1)); return err }, 1, false},
+		{"RemoveId", io.EOF, 1, func() error { _, err := m.RemoveId("user", 4); return err }, 2, true},
+		{"Aggregate $out", io.EOF, 1, func() error { var rs []bson.M; return m.Aggregate("user", &rs, []bson.M{{"$out": "x"}}) }, 1, false},
+	}
+	for _, cs := range cases {
+		calls, refreshes, fails, failErr = 0, 0, cs.fails, cs.err
+		err := cs.f()
+		if (err == nil) != cs.ok || calls != cs.calls || refreshes != calls-1 {
+			t.Errorf("%s: err=%v calls=%d refreshes=%d", cs.name, err, calls, refreshes)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	var failErr error
+	inject := func(ctx context.Context, op *Operation, next Invoker) error {
+		if failErr != nil {
+			return failErr
+		}
+		return next(ctx, op)
+	}
+	var states []string
+	b := newBreaker("bk", &BreakerPolicy{FailureRate: 0.5, MinRequests: 4, OpenDuration: 20 * time.Millisecond, HalfOpenProbes: 2,
+		OnStateChange: func(client string, from, to BreakerState) {
+			states = append(states, client+":"+from.String()+"->"+to.String())
+		}})
+	m := newInterceptMongo(&Config{Database: "test"}, newMemoryFixture(t), []Interceptor{b.interceptor(), inject})
+	count := func() error {
+		_, err := m.Count("user")
+		return err
+	}
+
+	// 业务错误不计为失败
+	failErr = errors.New("bad query")
+	for i := 0; i < 4; i++ {
+		count()
+	}
+	failErr = nil
+	count()
+	count()
+	failErr = io.EOF
+	count()
+	if err := count(); err != io.EOF {
+		t.Fatalf("closed: %v", err)
+	}
+	// 8次请求2次失败未达到0.5, 再失败4次后断开
+	for i := 0; i < 4; i++ {
+		count()
+	}
+	if err := count(); err != ErrCircuitOpen {
+		t.Fatalf("open: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := count(); err != io.EOF {
+		t.Fatalf("half-open probe: %v", err)
+	}
+	if err := count(); err != ErrCircuitOpen {
+		t.Fatalf("reopened: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	failErr = nil
+	if err := count(); err != nil {
+		t.Fatalf("half-open probe 1: %v", err)
+	}
+	if err := count(); err != nil {
+		t.Fatalf("half-open probe 2: %v", err)
+	}
+	if err := count(); err != nil {
+		t.Fatalf("closed again: %v", err)
+	}
+	want := []string{"bk:closed->open", "bk:open->half-open", "bk:half-open->open", "bk:open->half-open", "bk:half-open->closed"}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("OnStateChange: %v", states)
+	}
+
+	// 调用方的取消及超时不计为失败
+	states = nil
+	failErr = context.DeadlineExceeded
+	for i := 0; i < 8; i++ {
+		if err := count(); err != context.DeadlineExceeded {
+			t.Fatalf("deadline exceeded: %v", err)
+		}
+	}
+	if len(states) != 0 {
+		t.Errorf("deadline exceeded changed state: %v", states)
+	}
+}
+
+func TestBreakerGeneration(t *testing.T) {
+	b := newBreaker("bk", &BreakerPolicy{FailureRate: 0.5, MinRequests: 2, OpenDuration: 10 * time.Millisecond})
+	late, _ := b.allow() // 闭合时放行, 半开后才结束
+	for i := 0; i < 2; i++ {
+		gen, _ := b.allow()
+		b.done(gen, true)
+	}
+	if _, ok := b.allow(); ok || b.state != BreakerOpen {
+		t.Fatalf("open: %v", b.state)
+	}
+	time.Sleep(20 * time.Millisecond)
+	probe, ok := b.allow()
+	if !ok || b.state != BreakerHalfOpen {
+		t.Fatalf("half-open: %v", b.state)
+	}
+	b.done(late, false)
+	if b.state != BreakerHalfOpen || b.passed != 0 {
+		t.Errorf("late result counted: %v %v", b.state, b.passed)
+	}
+	b.done(probe, false)
+	if b.state != BreakerClosed {
+		t.Errorf("probe: %v", b.state)
+	}
+}
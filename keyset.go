@@ -0,0 +1,301 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid keyset token")
+	ErrKeysetSortKey = errors.New("keyset sort key missing in document")
+)
+
+// keysetToken 翻页令牌内容, 对调用方不透明
+type keysetToken struct {
+	Prev   bool          `bson:"p,omitempty"` // 向前翻页
+	Sort   []string      `bson:"s"`           // 生成令牌时的排序, 必须与本次一致
+	Values []interface{} `bson:"v"`           // 边界文档的排序键值
+}
+
+func (t *keysetToken) encode() (string, error) {
+	data, err := bson.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeKeysetToken(token string, sort []string) (*keysetToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	t := new(keysetToken)
+	if err = bson.Unmarshal(data, t); err != nil || len(t.Values) != len(sort) || !reflect.DeepEqual(t.Sort, sort) {
+		return nil, ErrInvalidToken
+	}
+	return t, nil
+}
+
+// keysetSort 拆分"-ctime,_id"形式的排序, 末尾没有_id时自动追加以保证顺序唯一
+func keysetSort(sort []string) ([]string, error) {
+	var ret []string
+	hasId := false
+	for _, s := range sort {
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimPrefix(strings.TrimSpace(f), "+")
+			if f == "" {
+				continue
+			}
+			name := strings.TrimPrefix(f, "-")
+			if name == "" || name[0] == '$' {
+				return nil, errors.New("unsupported keyset sort key: " + f)
+			}
+			if name == "_id" {
+				hasId = true
+			}
+			ret = append(ret, f)
+		}
+	}
+	if !hasId {
+		ret = append(ret, "_id")
+	}
+	return ret, nil
+}
+
+/*
+keysetQuery 生成边界条件, 例如排序"-ctime,_id"向后翻页:
+{$or: [{ctime: {$lt: v0}}, {ctime: v0, _id: {$gt: v1}}]}
+*/
+func keysetQuery(query interface{}, sort []string, t *keysetToken) interface{} {
+	or := make([]bson.M, len(sort))
+	for i, s := range sort {
+		cond := make(bson.M, i+1)
+		for j := 0; j < i; j++ {
+			cond[strings.TrimPrefix(sort[j], "-")] = t.Values[j]
+		}
+		op := "$gt"
+		if strings.HasPrefix(s, "-") != t.Prev {
+			op = "$lt"
+		}
+		cond[strings.TrimPrefix(s, "-")] = bson.M{op: t.Values[i]}
+		or[i] = cond
+	}
+	if query == nil {
+		return bson.M{"$or": or}
+	}
+	return bson.M{"$and": []interface{}{query, bson.M{"$or": or}}}
+}
+
+func reverseSort(sort []string) []string {
+	ret := make([]string, len(sort))
+	for i, s := range sort {
+		if strings.HasPrefix(s, "-") {
+			ret[i] = s[1:]
+		} else {
+			ret[i] = "-" + s
+		}
+	}
+	return ret
+}
+
+// newKeysetToken 从文档中取出排序键值生成令牌
+func newKeysetToken(raw bson.Raw, sort []string, prev bool) (string, error) {
+	var doc bson.M
+	if err := raw.Unmarshal(&doc); err != nil {
+		return "", err
+	}
+	t := &keysetToken{Prev: prev, Sort: sort, Values: make([]interface{}, len(sort))}
+	for i, s := range sort {
+		var v interface{} = doc
+		for _, p := range strings.Split(strings.TrimPrefix(s, "-"), ".") {
+			m, ok := v.(bson.M)
+			if !ok {
+				return "", ErrKeysetSortKey
+			}
+			if v, ok = m[p]; !ok {
+				return "", ErrKeysetSortKey
+			}
+		}
+		t.Values[i] = v
+	}
+	return t.encode()
+}
+
+// included 投影中字段的取值为true或非0数值
+func included(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case int, int32, int64, float64:
+		return toFloat(v) != 0
+	}
+	return false
+}
+
+// projectionIncludes 投影中有_id以外的字段被包含时为包含式投影
+func projectionIncludes(proj bson.M) bool {
+	for k, v := range proj {
+		if k != "_id" && included(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesPath 包含式投影的结果中是否有路径f, 未排除的_id默认包含
+func includesPath(proj bson.M, f string) bool {
+	if f == "_id" {
+		v, ok := proj["_id"]
+		return !ok || included(v)
+	}
+	for k, v := range proj {
+		if k != "_id" && (k == f || strings.HasPrefix(f, k+".")) && included(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathOverlaps a与b相同或互为前缀路径
+func pathOverlaps(a string, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+/*
+keysetProjection 保证排序键出现在结果中, 返回调整后的投影及解码前需要删除的字段:
+1. 包含式投影补上未包含的排序键, 包括被_id: 0排除的_id
+2. 排除式投影去掉与排序键重叠的字段
+*/
+func keysetProjection(projection interface{}, sort []string) (interface{}, []string, error) {
+	if projection == nil {
+		return nil, nil, nil
+	}
+	proj, err := toDoc(projection)
+	if err != nil {
+		return nil, nil, err
+	}
+	var strip []string
+	if projectionIncludes(proj) {
+		for _, s := range sort {
+			if f := strings.TrimPrefix(s, "-"); !includesPath(proj, f) {
+				proj[f] = 1
+				strip = append(strip, f)
+			}
+		}
+	} else {
+		for k := range proj {
+			for _, s := range sort {
+				if pathOverlaps(k, strings.TrimPrefix(s, "-")) {
+					delete(proj, k)
+					strip = append(strip, k)
+					break
+				}
+			}
+		}
+	}
+	return proj, strip, nil
+}
+
+// stripPath 删除文档中的路径, 删除后为空的上级文档一并删除
+func stripPath(doc bson.D, parts []string) bson.D {
+	for i, e := range doc {
+		if e.Name != parts[0] {
+			continue
+		}
+		if len(parts) > 1 {
+			sub, ok := e.Value.(bson.D)
+			if !ok {
+				return doc
+			}
+			if sub = stripPath(sub, parts[1:]); len(sub) > 0 {
+				doc[i].Value = sub
+				return doc
+			}
+		}
+		return append(doc[:i], doc[i+1:]...)
+	}
+	return doc
+}
+
+/*
+keyset 基于DBSelectRangeCtx实现的键集分页, 各实现共用:
+1. token为空时返回第一页, 否则从令牌记录的边界文档继续, 多取一条判断是否还有下一页
+2. 向前翻页时反转排序查询后再反转结果, 保证ret始终按sort顺序排列
+3. limit为0时返回剩余全部, next为空
+4. 投影排除的排序键在查询时补上, 解码前删除, 结果仍与投影一致
+*/
+func keyset(ctx context.Context, m Mongo, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort []string) (next string, prev string, err error) {
+	if sort, err = keysetSort(sort); err != nil {
+		return
+	}
+	t := &keysetToken{Sort: sort}
+	if token != "" {
+		if t, err = decodeKeysetToken(token, sort); err != nil {
+			return
+		}
+		query = keysetQuery(query, sort, t)
+	}
+	qsort := sort
+	if t.Prev {
+		qsort = reverseSort(sort)
+	}
+	fetch := limit
+	if limit > 0 {
+		fetch = limit + 1
+	}
+
+	projection, strip, err := keysetProjection(projection, sort)
+	if err != nil {
+		return
+	}
+	var raws []bson.Raw
+	if err = m.DBSelectRangeCtx(ctx, d, c, &raws, query, projection, 0, fetch, qsort...); err != nil {
+		return
+	}
+	more := limit > 0 && len(raws) > int(limit)
+	if more {
+		raws = raws[:limit]
+	}
+	if t.Prev {
+		for i, j := 0, len(raws)-1; i < j; i, j = i+1, j-1 {
+			raws[i], raws[j] = raws[j], raws[i]
+		}
+	}
+
+	if n := len(raws); n > 0 {
+		// 向后翻页时前面必有数据, 向前翻页时后面必有数据
+		if more || t.Prev {
+			if next, err = newKeysetToken(raws[n-1], sort, false); err != nil {
+				return
+			}
+		}
+		if t.Prev && more || !t.Prev && token != "" {
+			if prev, err = newKeysetToken(raws[0], sort, true); err != nil {
+				return
+			}
+		}
+	}
+
+	vals := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		if len(strip) == 0 {
+			vals[i] = raw
+			continue
+		}
+		var doc bson.D
+		if err = raw.Unmarshal(&doc); err != nil {
+			return
+		}
+		for _, f := range strip {
+			doc = stripPath(doc, strings.Split(f, "."))
+		}
+		vals[i] = doc
+	}
+	err = decodeValues(vals, ret)
+	return
+}
@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"sync"
+	"time"
+)
+
+// 后台重连的间隔, 每次失败翻倍直到上限
+var (
+	lazyRetryMin = time.Second
+	lazyRetryMax = 30 * time.Second
+)
+
+/*
+lazySession 延迟连接的根会话:
+1. 首次使用时连接, 并发的首次调用等待同一次连接
+2. 连接失败后在后台按间隔重连, 重连期间的调用立即返回最近一次的连接错误
+3. 连接成功后由mgo维护集群的重连
+*/
+type lazySession struct {
+	sync.Mutex
+	dial     func() (*mgo.Session, error)
+	session  *mgo.Session
+	err      error         // 最近一次连接错误
+	dialing  chan struct{} // 首次连接进行中时非nil, 结束后关闭
+	retrying bool
+	done     chan struct{} // close时关闭, 停止后台重连
+	closed   bool
+}
+
+func newLazySession(dial func() (*mgo.Session, error)) *lazySession {
+	return &lazySession{dial: dial, done: make(chan struct{})}
+}
+
+// current 返回已连接的根会话, 未连接返回nil
+func (l *lazySession) current() *mgo.Session {
+	l.Lock()
+	defer l.Unlock()
+	return l.session
+}
+
+func (l *lazySession) get(ctx context.Context) (*mgo.Session, error) {
+	l.Lock()
+	switch {
+	case l.session != nil:
+		ms := l.session
+		l.Unlock()
+		return ms, nil
+	case l.closed:
+		l.Unlock()
+		return nil, ErrClosed
+	case l.retrying:
+		err := l.err
+		l.Unlock()
+		return nil, err
+	case l.dialing == nil:
+		l.dialing = make(chan struct{})
+		go l.connect(l.dialing)
+	}
+	dialing := l.dialing
+	l.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-dialing:
+	}
+	l.Lock()
+	defer l.Unlock()
+	if l.session != nil {
+		return l.session, nil
+	}
+	return nil, l.err
+}
+
+func (l *lazySession) connect(dialing chan struct{}) {
+	ms, err := l.dial()
+	l.Lock()
+	defer l.Unlock()
+	defer close(dialing)
+	l.dialing = nil
+	if !l.set(ms, err) && !l.closed {
+		l.retrying = true
+		go l.retry()
+	}
+}
+
+func (l *lazySession) retry() {
+	wait := lazyRetryMin
+	for {
+		t := time.NewTimer(wait)
+		select {
+		case <-l.done:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		ms, err := l.dial()
+		l.Lock()
+		ok := l.set(ms, err)
+		if ok || l.closed {
+			l.retrying = false
+			l.Unlock()
+			return
+		}
+		l.Unlock()
+		if wait *= 2; wait > lazyRetryMax {
+			wait = lazyRetryMax
+		}
+	}
+}
+
+// set 记录连接结果, 已关闭时丢弃新连接. 返回是否连接成功
+func (l *lazySession) set(ms *mgo.Session, err error) bool {
+	if err != nil {
+		l.err = err
+		return false
+	}
+	if l.closed {
+		ms.Close()
+		l.err = ErrClosed
+		return false
+	}
+	l.session, l.err = ms, nil
+	return true
+}
+
+func (l *lazySession) close() {
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return
+	}
+	l.closed = true
+	close(l.done)
+	if l.session != nil {
+		l.session.Close()
+	}
+}
@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLazy(t *testing.T) {
+	defer UnregisterClient("lazy")
+	start := time.Now()
+	err := Setup("lazy", &Config{Address: []string{"127.0.0.1:1"}, Database: "test", ConnectTimeout: 100 * time.Millisecond, Lazy: true}, false)
+	if err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Fatalf("Setup lazy: %v %v", err, time.Since(start))
+	}
+	m := Get("lazy")
+	if _, err = m.Count("user"); err == nil {
+		t.Fatal("Count on unreachable server: expected error")
+	}
+	// 后台重连期间立即返回最近一次的连接错误
+	start = time.Now()
+	if err = m.Ping(context.Background()); err == nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("Ping while reconnecting: %v %v", err, time.Since(start))
+	}
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Ping(context.Background()); err != ErrClosed {
+		t.Errorf("Ping after close: %v", err)
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	if _, _, _, err := getConfig(map[interface{}]interface{}{"address": "127.0.0.1"}); err == nil {
+		t.Error("missing key: expected error")
+	}
+	if _, opt, _, _ := getConfig(map[interface{}]interface{}{"key": "x", "mode": "fastest"}); opt.Validate() == nil {
+		t.Error("invalid mode: expected error")
+	}
+	_, opt, _, err := getConfig(map[interface{}]interface{}{"key": "x", "mode": "secondary", "lazy": true})
+	if err != nil || !opt.Lazy || opt.Mode != GetMode("secondary") {
+		t.Errorf("getConfig: %v %+v", err, opt)
+	}
+}
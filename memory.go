@@ -0,0 +1,1675 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"sort"
+	"sync"
+)
+
+// ErrNotSupported 内存实现不支持的操作, 如RunCollection/RunSession需要真实的mgo会话
+var ErrNotSupported = errors.New("operation not supported by memory mongo")
+
+type memCollection struct {
+	docs    []bson.M
+	indexes []mgo.Index
+	info    *mgo.CollectionInfo // CreateCollection的选项, 只记录不生效
+}
+
+type memBulk struct {
+	ops []func(cl *memCollection) (matched int, modified int, err error)
+	m   *memMongo
+}
+
+func (b *memBulk) Insert(docs ...interface{}) {
+	b.ops = append(b.ops, func(cl *memCollection) (int, int, error) {
+		return 0, 0, b.m.insert(cl, docs)
+	})
+}
+func (b *memBulk) Upsert(pairs ...interface{}) {
+	b.pairs(pairs, func(cl *memCollection, selector interface{}, update interface{}) (int, int, error) {
+		ci, err := b.m.update(cl, selector, update, false, true)
+		if err != nil {
+			return 0, 0, err
+		}
+		return ci.Matched + upserted(ci), ci.Updated, nil
+	})
+}
+func (b *memBulk) RemoveOne(selectors ...interface{}) {
+	for _, selector := range selectors {
+		selector := selector
+		b.ops = append(b.ops, func(cl *memCollection) (int, int, error) {
+			n, err := b.m.remove(cl, selector, false)
+			return n, 0, err
+		})
+	}
+}
+func (b *memBulk) RemoveAll(selectors ...interface{}) {
+	for _, selector := range selectors {
+		selector := selector
+		b.ops = append(b.ops, func(cl *memCollection) (int, int, error) {
+			n, err := b.m.remove(cl, selector, true)
+			return n, 0, err
+		})
+	}
+}
+func (b *memBulk) UpdateOne(pairs ...interface{}) {
+	b.pairs(pairs, func(cl *memCollection, selector interface{}, update interface{}) (int, int, error) {
+		ci, err := b.m.update(cl, selector, update, false, false)
+		if err != nil {
+			return 0, 0, err
+		}
+		return ci.Matched, ci.Updated, nil
+	})
+}
+func (b *memBulk) UpdateAll(pairs ...interface{}) {
+	b.pairs(pairs, func(cl *memCollection, selector interface{}, update interface{}) (int, int, error) {
+		ci, err := b.m.update(cl, selector, update, true, false)
+		if err != nil {
+			return 0, 0, err
+		}
+		return ci.Matched, ci.Updated, nil
+	})
+}
+
+func (b *memBulk) pairs(pairs []interface{}, f func(cl *memCollection, selector interface{}, update interface{}) (int, int, error)) {
+	if len(pairs)%2 != 0 {
+		panic("Bulk update requires an even number of parameters")
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		selector, update := pairs[i], pairs[i+1]
+		b.ops = append(b.ops, func(cl *memCollection) (int, int, error) {
+			return f(cl, selector, update)
+		})
+	}
+}
+
+func upserted(ci *mgo.ChangeInfo) int {
+	if ci.UpsertedId != nil {
+		return 1
+	}
+	return 0
+}
+
+/*
+memMongo 纯内存的Mongo实现, 用于单元测试. 通过Config.Memory在Setup时注册.
+查询, 更新, 返回值语义与gsSession一致; RunCollection/RunSession返回ErrNotSupported.
+*/
+type memMongo struct {
+	*Config
+	sync.RWMutex
+	dbs  map[string]map[string]*memCollection
+	life lifecycle
+}
+
+func newMemoryMongo(opt *Config) *memMongo {
+	return &memMongo{
+		Config: opt,
+		dbs:    make(map[string]map[string]*memCollection),
+	}
+}
+
+func (m *memMongo) Count(c string) (n int, err error) {
+	return m.DBCount(m.Config.Database, c)
+}
+func (m *memMongo) CountWhere(c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return m.DBCountWhere(m.Config.Database, c, query, opts...)
+}
+func (m *memMongo) EstimatedCount(c string) (n int, err error) {
+	return m.DBEstimatedCount(m.Config.Database, c)
+}
+func (m *memMongo) Exists(c string, query interface{}) (ok bool, err error) {
+	return m.DBExists(m.Config.Database, c, query)
+}
+func (m *memMongo) Indexes(c string) (indexes []mgo.Index, err error) {
+	return m.DBIndexes(m.Config.Database, c)
+}
+func (m *memMongo) EnsureIndex(c string, index mgo.Index) (err error) {
+	return m.DBEnsureIndex(m.Config.Database, c, index)
+}
+func (m *memMongo) EnsureIndexKey(c string, key ...string) (err error) {
+	return m.DBEnsureIndexKey(m.Config.Database, c, key...)
+}
+func (m *memMongo) DropIndex(c string, key ...string) error {
+	return m.DBDropIndex(m.Config.Database, c, key...)
+}
+func (m *memMongo) DropIndexName(c string, name string) error {
+	return m.DBDropIndexName(m.Config.Database, c, name)
+}
+func (m *memMongo) CreateCollection(c string, info *mgo.CollectionInfo) error {
+	return m.DBCreateCollection(m.Config.Database, c, info)
+}
+func (m *memMongo) DropCollection(c string) error {
+	return m.DBDropCollection(m.Config.Database, c)
+}
+func (m *memMongo) RenameCollection(c string, to string, dropTarget bool) error {
+	return m.DBRenameCollection(m.Config.Database, c, to, dropTarget)
+}
+func (m *memMongo) CollectionStats(c string) (stats *CollStats, err error) {
+	return m.DBCollectionStats(m.Config.Database, c)
+}
+func (m *memMongo) ListCollections() (specs []CollectionSpec, err error) {
+	return m.DBListCollections(m.Config.Database)
+}
+func (m *memMongo) DropDatabase() error {
+	return m.DBDropDatabase(m.Config.Database)
+}
+func (m *memMongo) DatabaseStats() (stats *DBStats, err error) {
+	return m.DBDatabaseStats(m.Config.Database)
+}
+func (m *memMongo) ListDatabases() (specs []DatabaseSpec, err error) {
+	return m.ListDatabasesCtx(context.Background())
+}
+func (m *memMongo) FindOne(c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return m.DBFindOne(m.Config.Database, c, ret, query)
+}
+func (m *memMongo) FindAll(c string, ret interface{}, query interface{}, sort ...string) error {
+	return m.DBFindAll(m.Config.Database, c, ret, query, sort...)
+}
+
+func (m *memMongo) FindRange(c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindRange(m.Config.Database, c, ret, query, skip, limit, sort...)
+}
+
+func (m *memMongo) FindPage(c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindPage(m.Config.Database, c, tot, ret, query, skip, limit, sort...)
+}
+
+func (m *memMongo) FindDistinct(c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return m.DBFindDistinct(m.Config.Database, c, ret, query, key, sort...)
+}
+
+func (m *memMongo) FindId(c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return m.DBFindId(m.Config.Database, c, ret, id)
+}
+
+func (m *memMongo) SelectOne(c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectOne(m.Config.Database, c, ret, query, projection)
+}
+func (m *memMongo) SelectAll(c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return m.DBSelectAll(m.Config.Database, c, ret, query, projection, sort...)
+}
+func (m *memMongo) SelectRange(c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectRange(m.Config.Database, c, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) SelectPage(c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectPage(m.Config.Database, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) SelectDistinct(c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return m.DBSelectDistinct(m.Config.Database, c, ret, query, projection, key, sort...)
+}
+func (m *memMongo) SelectId(c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectId(m.Config.Database, c, ret, id, projection)
+}
+func (m *memMongo) Aggregate(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return m.DBAggregate(m.Config.Database, c, ret, pipeline, opts...)
+}
+func (m *memMongo) AggregateOne(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return m.DBAggregateOne(m.Config.Database, c, ret, pipeline, opts...)
+}
+func (m *memMongo) FindIter(c string, query interface{}, batch int, sort ...string) Iter {
+	return m.DBFindIter(m.Config.Database, c, query, batch, sort...)
+}
+func (m *memMongo) SelectIter(c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return m.DBSelectIter(m.Config.Database, c, query, projection, batch, sort...)
+}
+func (m *memMongo) FindKeyset(c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBFindKeyset(m.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (m *memMongo) SelectKeyset(c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBSelectKeyset(m.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
+
+func (m *memMongo) FindAndUpdate(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdate(m.Config.Database, c, ret, query, update)
+}
+func (m *memMongo) FindAndUpsert(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsert(m.Config.Database, c, ret, query, upsert)
+}
+func (m *memMongo) FindAndRemove(c string, ret interface{}, query interface{}) (removed int, err error) {
+	return m.DBFindAndRemove(m.Config.Database, c, ret, query)
+}
+func (m *memMongo) FindAndUpdateRN(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdateRN(m.Config.Database, c, ret, query, update)
+}
+func (m *memMongo) FindAndUpsertRN(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsertRN(m.Config.Database, c, ret, query, upsert)
+}
+
+func (m *memMongo) Insert(c string, docs ...interface{}) (err error) {
+	return m.DBInsert(m.Config.Database, c, docs...)
+}
+func (m *memMongo) RemoveOne(c string, selector interface{}) (ok bool, err error) {
+	return m.DBRemoveOne(m.Config.Database, c, selector)
+}
+func (m *memMongo) RemoveAll(c string, selector interface{}) (removed int, err error) {
+	return m.DBRemoveAll(m.Config.Database, c, selector)
+}
+func (m *memMongo) RemoveId(c string, id interface{}) (ok bool, err error) {
+	return m.DBRemoveId(m.Config.Database, c, id)
+}
+func (m *memMongo) UpdateOne(c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateOne(m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpdateAll(c string, selector interface{}, update interface{}) (updated int, err error) {
+	return m.DBUpdateAll(m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpdateId(c string, id interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateId(m.Config.Database, c, id, update)
+}
+func (m *memMongo) UpsertOne(c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertOne(m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpsertId(c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertId(m.Config.Database, c, id, update)
+}
+func (m *memMongo) RunBulk(c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return m.DBRunBulk(m.Config.Database, c, f, args...)
+}
+
+func (m *memMongo) RunCollection(c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return m.DBRunCollection(m.Config.Database, c, f, args...)
+}
+
+func (m *memMongo) CountCtx(ctx context.Context, c string) (n int, err error) {
+	return m.DBCountCtx(ctx, m.Config.Database, c)
+}
+func (m *memMongo) CountWhereCtx(ctx context.Context, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return m.DBCountWhereCtx(ctx, m.Config.Database, c, query, opts...)
+}
+func (m *memMongo) EstimatedCountCtx(ctx context.Context, c string) (n int, err error) {
+	return m.DBEstimatedCountCtx(ctx, m.Config.Database, c)
+}
+func (m *memMongo) ExistsCtx(ctx context.Context, c string, query interface{}) (ok bool, err error) {
+	return m.DBExistsCtx(ctx, m.Config.Database, c, query)
+}
+func (m *memMongo) IndexesCtx(ctx context.Context, c string) (indexes []mgo.Index, err error) {
+	return m.DBIndexesCtx(ctx, m.Config.Database, c)
+}
+func (m *memMongo) EnsureIndexCtx(ctx context.Context, c string, index mgo.Index) (err error) {
+	return m.DBEnsureIndexCtx(ctx, m.Config.Database, c, index)
+}
+func (m *memMongo) EnsureIndexKeyCtx(ctx context.Context, c string, key ...string) (err error) {
+	return m.DBEnsureIndexKeyCtx(ctx, m.Config.Database, c, key...)
+}
+func (m *memMongo) DropIndexCtx(ctx context.Context, c string, key ...string) error {
+	return m.DBDropIndexCtx(ctx, m.Config.Database, c, key...)
+}
+func (m *memMongo) DropIndexNameCtx(ctx context.Context, c string, name string) error {
+	return m.DBDropIndexNameCtx(ctx, m.Config.Database, c, name)
+}
+func (m *memMongo) CreateCollectionCtx(ctx context.Context, c string, info *mgo.CollectionInfo) error {
+	return m.DBCreateCollectionCtx(ctx, m.Config.Database, c, info)
+}
+func (m *memMongo) DropCollectionCtx(ctx context.Context, c string) error {
+	return m.DBDropCollectionCtx(ctx, m.Config.Database, c)
+}
+func (m *memMongo) RenameCollectionCtx(ctx context.Context, c string, to string, dropTarget bool) error {
+	return m.DBRenameCollectionCtx(ctx, m.Config.Database, c, to, dropTarget)
+}
+func (m *memMongo) CollectionStatsCtx(ctx context.Context, c string) (stats *CollStats, err error) {
+	return m.DBCollectionStatsCtx(ctx, m.Config.Database, c)
+}
+func (m *memMongo) ListCollectionsCtx(ctx context.Context) (specs []CollectionSpec, err error) {
+	return m.DBListCollectionsCtx(ctx, m.Config.Database)
+}
+func (m *memMongo) DropDatabaseCtx(ctx context.Context) error {
+	return m.DBDropDatabaseCtx(ctx, m.Config.Database)
+}
+func (m *memMongo) DatabaseStatsCtx(ctx context.Context) (stats *DBStats, err error) {
+	return m.DBDatabaseStatsCtx(ctx, m.Config.Database)
+}
+func (m *memMongo) FindOneCtx(ctx context.Context, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return m.DBFindOneCtx(ctx, m.Config.Database, c, ret, query)
+}
+func (m *memMongo) FindAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, sort ...string) error {
+	return m.DBFindAllCtx(ctx, m.Config.Database, c, ret, query, sort...)
+}
+func (m *memMongo) FindRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindRangeCtx(ctx, m.Config.Database, c, ret, query, skip, limit, sort...)
+}
+func (m *memMongo) FindPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindPageCtx(ctx, m.Config.Database, c, tot, ret, query, skip, limit, sort...)
+}
+func (m *memMongo) FindDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return m.DBFindDistinctCtx(ctx, m.Config.Database, c, ret, query, key, sort...)
+}
+func (m *memMongo) FindIdCtx(ctx context.Context, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return m.DBFindIdCtx(ctx, m.Config.Database, c, ret, id)
+}
+func (m *memMongo) SelectOneCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectOneCtx(ctx, m.Config.Database, c, ret, query, projection)
+}
+func (m *memMongo) SelectAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return m.DBSelectAllCtx(ctx, m.Config.Database, c, ret, query, projection, sort...)
+}
+func (m *memMongo) SelectRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectRangeCtx(ctx, m.Config.Database, c, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) SelectPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectPageCtx(ctx, m.Config.Database, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) SelectDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return m.DBSelectDistinctCtx(ctx, m.Config.Database, c, ret, query, projection, key, sort...)
+}
+func (m *memMongo) SelectIdCtx(ctx context.Context, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectIdCtx(ctx, m.Config.Database, c, ret, id, projection)
+}
+func (m *memMongo) AggregateCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return m.DBAggregateCtx(ctx, m.Config.Database, c, ret, pipeline, opts...)
+}
+func (m *memMongo) AggregateOneCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return m.DBAggregateOneCtx(ctx, m.Config.Database, c, ret, pipeline, opts...)
+}
+func (m *memMongo) FindIterCtx(ctx context.Context, c string, query interface{}, batch int, sort ...string) Iter {
+	return m.DBFindIterCtx(ctx, m.Config.Database, c, query, batch, sort...)
+}
+func (m *memMongo) SelectIterCtx(ctx context.Context, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return m.DBSelectIterCtx(ctx, m.Config.Database, c, query, projection, batch, sort...)
+}
+func (m *memMongo) FindKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBFindKeysetCtx(ctx, m.Config.Database, c, ret, query, token, limit, sort...)
+}
+func (m *memMongo) SelectKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBSelectKeysetCtx(ctx, m.Config.Database, c, ret, query, projection, token, limit, sort...)
+}
+
+func (m *memMongo) FindAndUpdateCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdateCtx(ctx, m.Config.Database, c, ret, query, update)
+}
+func (m *memMongo) FindAndUpsertCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsertCtx(ctx, m.Config.Database, c, ret, query, upsert)
+}
+func (m *memMongo) FindAndRemoveCtx(ctx context.Context, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return m.DBFindAndRemoveCtx(ctx, m.Config.Database, c, ret, query)
+}
+func (m *memMongo) FindAndUpdateRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdateRNCtx(ctx, m.Config.Database, c, ret, query, update)
+}
+func (m *memMongo) FindAndUpsertRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsertRNCtx(ctx, m.Config.Database, c, ret, query, upsert)
+}
+
+func (m *memMongo) InsertCtx(ctx context.Context, c string, docs ...interface{}) (err error) {
+	return m.DBInsertCtx(ctx, m.Config.Database, c, docs...)
+}
+func (m *memMongo) RemoveOneCtx(ctx context.Context, c string, selector interface{}) (ok bool, err error) {
+	return m.DBRemoveOneCtx(ctx, m.Config.Database, c, selector)
+}
+func (m *memMongo) RemoveAllCtx(ctx context.Context, c string, selector interface{}) (removed int, err error) {
+	return m.DBRemoveAllCtx(ctx, m.Config.Database, c, selector)
+}
+func (m *memMongo) RemoveIdCtx(ctx context.Context, c string, id interface{}) (ok bool, err error) {
+	return m.DBRemoveIdCtx(ctx, m.Config.Database, c, id)
+}
+func (m *memMongo) UpdateOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateOneCtx(ctx, m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpdateAllCtx(ctx context.Context, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return m.DBUpdateAllCtx(ctx, m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpdateIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateIdCtx(ctx, m.Config.Database, c, id, update)
+}
+func (m *memMongo) UpsertOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertOneCtx(ctx, m.Config.Database, c, selector, update)
+}
+func (m *memMongo) UpsertIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertIdCtx(ctx, m.Config.Database, c, id, update)
+}
+func (m *memMongo) RunBulkCtx(ctx context.Context, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return m.DBRunBulkCtx(ctx, m.Config.Database, c, f, args...)
+}
+
+func (m *memMongo) RunCollectionCtx(ctx context.Context, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return m.DBRunCollectionCtx(ctx, m.Config.Database, c, f, args...)
+}
+
+func (m *memMongo) DBCount(d string, c string) (n int, err error) {
+	return m.DBCountCtx(context.Background(), d, c)
+}
+func (m *memMongo) DBCountWhere(d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return m.DBCountWhereCtx(context.Background(), d, c, query, opts...)
+}
+func (m *memMongo) DBEstimatedCount(d string, c string) (n int, err error) {
+	return m.DBEstimatedCountCtx(context.Background(), d, c)
+}
+func (m *memMongo) DBExists(d string, c string, query interface{}) (ok bool, err error) {
+	return m.DBExistsCtx(context.Background(), d, c, query)
+}
+func (m *memMongo) DBIndexes(d string, c string) (indexes []mgo.Index, err error) {
+	return m.DBIndexesCtx(context.Background(), d, c)
+}
+func (m *memMongo) DBEnsureIndex(d string, c string, index mgo.Index) (err error) {
+	return m.DBEnsureIndexCtx(context.Background(), d, c, index)
+}
+func (m *memMongo) DBEnsureIndexKey(d string, c string, key ...string) (err error) {
+	return m.DBEnsureIndexKeyCtx(context.Background(), d, c, key...)
+}
+func (m *memMongo) DBDropIndex(d string, c string, key ...string) error {
+	return m.DBDropIndexCtx(context.Background(), d, c, key...)
+}
+func (m *memMongo) DBDropIndexName(d string, c string, name string) error {
+	return m.DBDropIndexNameCtx(context.Background(), d, c, name)
+}
+func (m *memMongo) DBCreateCollection(d string, c string, info *mgo.CollectionInfo) error {
+	return m.DBCreateCollectionCtx(context.Background(), d, c, info)
+}
+func (m *memMongo) DBDropCollection(d string, c string) error {
+	return m.DBDropCollectionCtx(context.Background(), d, c)
+}
+func (m *memMongo) DBRenameCollection(d string, c string, to string, dropTarget bool) error {
+	return m.DBRenameCollectionCtx(context.Background(), d, c, to, dropTarget)
+}
+func (m *memMongo) DBCollectionStats(d string, c string) (stats *CollStats, err error) {
+	return m.DBCollectionStatsCtx(context.Background(), d, c)
+}
+func (m *memMongo) DBListCollections(d string) (specs []CollectionSpec, err error) {
+	return m.DBListCollectionsCtx(context.Background(), d)
+}
+func (m *memMongo) DBDropDatabase(d string) error {
+	return m.DBDropDatabaseCtx(context.Background(), d)
+}
+func (m *memMongo) DBDatabaseStats(d string) (stats *DBStats, err error) {
+	return m.DBDatabaseStatsCtx(context.Background(), d)
+}
+func (m *memMongo) DBFindOne(d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return m.DBFindOneCtx(context.Background(), d, c, ret, query)
+}
+func (m *memMongo) DBFindAll(d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return m.DBFindAllCtx(context.Background(), d, c, ret, query, sort...)
+}
+func (m *memMongo) DBFindRange(d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindRangeCtx(context.Background(), d, c, ret, query, skip, limit, sort...)
+}
+func (m *memMongo) DBFindPage(d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBFindPageCtx(context.Background(), d, c, tot, ret, query, skip, limit, sort...)
+}
+func (m *memMongo) DBFindDistinct(d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return m.DBFindDistinctCtx(context.Background(), d, c, ret, query, key, sort...)
+}
+func (m *memMongo) DBFindId(d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return m.DBFindIdCtx(context.Background(), d, c, ret, id)
+}
+func (m *memMongo) DBSelectOne(d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectOneCtx(context.Background(), d, c, ret, query, projection)
+}
+func (m *memMongo) DBSelectAll(d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return m.DBSelectAllCtx(context.Background(), d, c, ret, query, projection, sort...)
+}
+func (m *memMongo) DBSelectRange(d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectRangeCtx(context.Background(), d, c, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) DBSelectPage(d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.DBSelectPageCtx(context.Background(), d, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func (m *memMongo) DBSelectDistinct(d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return m.DBSelectDistinctCtx(context.Background(), d, c, ret, query, projection, key, sort...)
+}
+func (m *memMongo) DBSelectId(d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return m.DBSelectIdCtx(context.Background(), d, c, ret, id, projection)
+}
+func (m *memMongo) DBAggregate(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return m.DBAggregateCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+func (m *memMongo) DBAggregateOne(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	return m.DBAggregateOneCtx(context.Background(), d, c, ret, pipeline, opts...)
+}
+func (m *memMongo) DBFindIter(d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return m.DBFindIterCtx(context.Background(), d, c, query, batch, sort...)
+}
+func (m *memMongo) DBSelectIter(d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return m.DBSelectIterCtx(context.Background(), d, c, query, projection, batch, sort...)
+}
+func (m *memMongo) DBFindKeyset(d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBFindKeysetCtx(context.Background(), d, c, ret, query, token, limit, sort...)
+}
+func (m *memMongo) DBSelectKeyset(d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return m.DBSelectKeysetCtx(context.Background(), d, c, ret, query, projection, token, limit, sort...)
+}
+func (m *memMongo) DBFindAndUpdate(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdateCtx(context.Background(), d, c, ret, query, update)
+}
+func (m *memMongo) DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsertCtx(context.Background(), d, c, ret, query, upsert)
+}
+func (m *memMongo) DBFindAndRemove(d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return m.DBFindAndRemoveCtx(context.Background(), d, c, ret, query)
+}
+func (m *memMongo) DBFindAndUpdateRN(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return m.DBFindAndUpdateRNCtx(context.Background(), d, c, ret, query, update)
+}
+func (m *memMongo) DBFindAndUpsertRN(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return m.DBFindAndUpsertRNCtx(context.Background(), d, c, ret, query, upsert)
+}
+func (m *memMongo) DBInsert(d string, c string, docs ...interface{}) (err error) {
+	return m.DBInsertCtx(context.Background(), d, c, docs...)
+}
+func (m *memMongo) DBRemoveOne(d string, c string, selector interface{}) (ok bool, err error) {
+	return m.DBRemoveOneCtx(context.Background(), d, c, selector)
+}
+func (m *memMongo) DBRemoveAll(d string, c string, selector interface{}) (removed int, err error) {
+	return m.DBRemoveAllCtx(context.Background(), d, c, selector)
+}
+func (m *memMongo) DBRemoveId(d string, c string, id interface{}) (ok bool, err error) {
+	return m.DBRemoveIdCtx(context.Background(), d, c, id)
+}
+func (m *memMongo) DBUpdateOne(d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateOneCtx(context.Background(), d, c, selector, update)
+}
+func (m *memMongo) DBUpdateAll(d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return m.DBUpdateAllCtx(context.Background(), d, c, selector, update)
+}
+func (m *memMongo) DBUpdateId(d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateIdCtx(context.Background(), d, c, id, update)
+}
+func (m *memMongo) DBUpsertOne(d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertOneCtx(context.Background(), d, c, selector, update)
+}
+func (m *memMongo) DBUpsertId(d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertIdCtx(context.Background(), d, c, id, update)
+}
+func (m *memMongo) DBRunBulk(d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return m.DBRunBulkCtx(context.Background(), d, c, f, args...)
+}
+func (m *memMongo) DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return m.DBRunCollectionCtx(context.Background(), d, c, f, args...)
+}
+func (m *memMongo) RunSession(f SessionFunc, args ...interface{}) (interface{}, error) {
+	return m.RunSessionCtx(context.Background(), f, args...)
+}
+
+func (m *memMongo) DBCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		if cl != nil {
+			n = len(cl.docs)
+		}
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBCountWhereCtx(ctx context.Context, d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	opt := countOptions(opts)
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		idx, err := cl.match(query)
+		if err != nil {
+			return err
+		}
+		n = len(idx) - int(opt.Skip)
+		if n < 0 {
+			n = 0
+		}
+		if opt.Limit > 0 && n > int(opt.Limit) {
+			n = int(opt.Limit)
+		}
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBEstimatedCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	return m.DBCountCtx(ctx, d, c)
+}
+func (m *memMongo) DBExistsCtx(ctx context.Context, d string, c string, query interface{}) (ok bool, err error) {
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		if cl == nil {
+			return nil
+		}
+		q, err := toDoc(query)
+		if err != nil {
+			return err
+		}
+		for _, doc := range cl.docs {
+			if ok, err = matchDoc(doc, q); ok || err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBIndexesCtx(ctx context.Context, d string, c string) (indexes []mgo.Index, err error) {
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		if cl == nil {
+			return &mgo.QueryError{Code: 26, Message: "ns does not exist: " + d + "." + c}
+		}
+		indexes = append([]mgo.Index{{Name: "_id_", Key: []string{"_id"}}}, cl.indexes...)
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBEnsureIndexCtx(ctx context.Context, d string, c string, index mgo.Index) (err error) {
+	return m.write(ctx, d, c, func(cl *memCollection) error {
+		name, err := indexName(index.Key)
+		if err != nil {
+			return err
+		}
+		if index.Name == "" {
+			index.Name = name
+		}
+		for _, idx := range cl.indexes {
+			if idx.Name == index.Name {
+				return nil
+			}
+		}
+		if index.Unique {
+			for i, doc := range cl.docs {
+				if err = checkUnique(cl.docs[:i], doc, index); err != nil {
+					return err
+				}
+			}
+		}
+		cl.indexes = append(cl.indexes, index)
+		return nil
+	})
+}
+func (m *memMongo) DBEnsureIndexKeyCtx(ctx context.Context, d string, c string, key ...string) (err error) {
+	return m.DBEnsureIndexCtx(ctx, d, c, mgo.Index{Key: key})
+}
+func (m *memMongo) DBDropIndexCtx(ctx context.Context, d string, c string, key ...string) error {
+	name, err := indexName(key)
+	if err != nil {
+		return err
+	}
+	return m.DBDropIndexNameCtx(ctx, d, c, name)
+}
+func (m *memMongo) DBDropIndexNameCtx(ctx context.Context, d string, c string, name string) error {
+	return m.write(ctx, d, c, func(cl *memCollection) error {
+		for i, idx := range cl.indexes {
+			if idx.Name == name {
+				cl.indexes = append(cl.indexes[:i], cl.indexes[i+1:]...)
+				return nil
+			}
+		}
+		return &mgo.QueryError{Code: 27, Message: "index not found with name [" + name + "]"}
+	})
+}
+func (m *memMongo) DBCreateCollectionCtx(ctx context.Context, d string, c string, info *mgo.CollectionInfo) error {
+	if info == nil {
+		info = new(mgo.CollectionInfo)
+	}
+	if info.Capped && info.MaxBytes < 1 {
+		return errors.New("Collection.Create: with Capped, MaxBytes must also be set")
+	}
+	return m.admin(ctx, true, func() error {
+		if m.dbs[d][c] != nil {
+			return &mgo.QueryError{Code: 48, Message: "collection already exists. NS: " + d + "." + c}
+		}
+		cp := *info
+		m.collection(d, c).info = &cp
+		return nil
+	})
+}
+func (m *memMongo) DBDropCollectionCtx(ctx context.Context, d string, c string) error {
+	return m.admin(ctx, true, func() error {
+		if db := m.dbs[d]; db != nil {
+			delete(db, c)
+			if len(db) == 0 {
+				delete(m.dbs, d)
+			}
+		}
+		return nil
+	})
+}
+func (m *memMongo) DBRenameCollectionCtx(ctx context.Context, d string, c string, to string, dropTarget bool) error {
+	return m.admin(ctx, true, func() error {
+		cl := m.dbs[d][c]
+		switch {
+		case cl == nil:
+			return &mgo.QueryError{Code: 26, Message: "source namespace does not exist"}
+		case c == to:
+			return &mgo.QueryError{Code: 20, Message: "Can't rename a collection to itself"}
+		case m.dbs[d][to] != nil && !dropTarget:
+			return &mgo.QueryError{Code: 48, Message: "target namespace exists"}
+		}
+		m.dbs[d][to] = cl
+		delete(m.dbs[d], c)
+		return nil
+	})
+}
+func (m *memMongo) DBCollectionStatsCtx(ctx context.Context, d string, c string) (stats *CollStats, err error) {
+	err = m.admin(ctx, false, func() error {
+		stats = m.dbs[d][c].stats()
+		stats.Ns = d + "." + c
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBListCollectionsCtx(ctx context.Context, d string) (specs []CollectionSpec, err error) {
+	err = m.admin(ctx, false, func() error {
+		for c, cl := range m.dbs[d] {
+			info := new(mgo.CollectionInfo)
+			if cl.info != nil {
+				*info = *cl.info
+			}
+			specs = append(specs, CollectionSpec{Name: c, Type: "collection", Info: info})
+		}
+		sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBDropDatabaseCtx(ctx context.Context, d string) error {
+	return m.admin(ctx, true, func() error {
+		delete(m.dbs, d)
+		return nil
+	})
+}
+func (m *memMongo) DBDatabaseStatsCtx(ctx context.Context, d string) (stats *DBStats, err error) {
+	err = m.admin(ctx, false, func() error {
+		stats = &DBStats{DB: d, Collections: len(m.dbs[d])}
+		for _, cl := range m.dbs[d] {
+			cs := cl.stats()
+			stats.Objects += int64(cs.Count)
+			stats.DataSize += cs.Size
+			stats.Indexes += cs.Nindexes
+		}
+		stats.StorageSize = stats.DataSize
+		if stats.Objects > 0 {
+			stats.AvgObjSize = float64(stats.DataSize) / float64(stats.Objects)
+		}
+		return nil
+	})
+	return
+}
+func (m *memMongo) ListDatabasesCtx(ctx context.Context) (specs []DatabaseSpec, err error) {
+	err = m.admin(ctx, false, func() error {
+		for d, db := range m.dbs {
+			spec := DatabaseSpec{Name: d}
+			for _, cl := range db {
+				spec.SizeOnDisk += cl.stats().Size
+			}
+			spec.Empty = spec.SizeOnDisk == 0
+			specs = append(specs, spec)
+		}
+		sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBFindOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (ok bool, err error) {
+	return m.findOne(ctx, d, c, ret, query, nil)
+}
+func (m *memMongo) DBFindAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return m.findAll(ctx, d, c, nil, ret, query, nil, 0, 0, sort)
+}
+func (m *memMongo) DBFindRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.findAll(ctx, d, c, nil, ret, query, nil, skip, limit, sort)
+}
+func (m *memMongo) DBFindPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.findAll(ctx, d, c, tot, ret, query, nil, skip, limit, sort)
+}
+func (m *memMongo) DBFindDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return m.distinct(ctx, d, c, ret, query, key)
+}
+func (m *memMongo) DBFindIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}) (ok bool, err error) {
+	return m.findOne(ctx, d, c, ret, bson.M{"_id": id}, nil)
+}
+func (m *memMongo) DBSelectOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	return m.findOne(ctx, d, c, ret, query, projection)
+}
+func (m *memMongo) DBSelectAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return m.findAll(ctx, d, c, nil, ret, query, projection, 0, 0, sort)
+}
+func (m *memMongo) DBSelectRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.findAll(ctx, d, c, nil, ret, query, projection, skip, limit, sort)
+}
+func (m *memMongo) DBSelectPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return m.findAll(ctx, d, c, tot, ret, query, projection, skip, limit, sort)
+}
+func (m *memMongo) DBSelectDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return m.distinct(ctx, d, c, ret, query, key)
+}
+func (m *memMongo) DBSelectIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}, projection interface{}) (ok bool, err error) {
+	return m.findOne(ctx, d, c, ret, bson.M{"_id": id}, projection)
+}
+
+func (m *memMongo) DBAggregateCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	if plan := explainPlan(ctx); plan != nil {
+		return m.explainPipe(ctx, d, c, plan, pipeline)
+	}
+	return m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.aggregate(pipeline)
+		if err != nil {
+			return err
+		}
+		vals := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			vals[i] = doc
+		}
+		return decodeValues(vals, ret)
+	})
+}
+func (m *memMongo) DBAggregateOneCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (ok bool, err error) {
+	if plan := explainPlan(ctx); plan != nil {
+		return false, m.explainPipe(ctx, d, c, plan, pipeline)
+	}
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.aggregate(pipeline)
+		if err != nil || len(docs) == 0 {
+			return err
+		}
+		ok = true
+		return decodeDoc(docs[0], ret)
+	})
+	return
+}
+func (m *memMongo) DBFindIterCtx(ctx context.Context, d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return m.DBSelectIterCtx(ctx, d, c, query, nil, batch, sort...)
+}
+func (m *memMongo) DBSelectIterCtx(ctx context.Context, d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	if plan := explainPlan(ctx); plan != nil {
+		return &errIter{err: m.explain(ctx, d, c, plan, query, sort)}
+	}
+	var docs []bson.M
+	err := m.read(ctx, d, c, func(cl *memCollection) error {
+		all, err := cl.sorted(query, sort)
+		if err != nil {
+			return err
+		}
+		docs = make([]bson.M, len(all))
+		for i, doc := range all {
+			if doc, err = projectDoc(doc, projection); err != nil {
+				return err
+			}
+			docs[i] = cloneDoc(doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return &errIter{err: err}
+	}
+	return &memIter{ctx: ctx, docs: docs}
+}
+func (m *memMongo) DBFindKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return keyset(ctx, m, d, c, ret, query, nil, token, limit, sort)
+}
+func (m *memMongo) DBSelectKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return keyset(ctx, m, d, c, ret, query, projection, token, limit, sort)
+}
+
+func (m *memMongo) DBFindAndUpdateCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	if err = m.checkUpdate(update); err != nil {
+		return
+	}
+	ci, err := m.findAndModify(ctx, d, c, ret, query, mgo.Change{Update: update})
+	if err != nil {
+		return
+	}
+	updated = ci.Updated
+	return
+}
+func (m *memMongo) DBFindAndUpsertCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	if err = m.checkUpdate(upsert); err != nil {
+		return
+	}
+	ci, err := m.findAndModify(ctx, d, c, ret, query, mgo.Change{Update: upsert, Upsert: true})
+	if err != nil {
+		return
+	}
+	upsertedId = ci.UpsertedId
+	return
+}
+func (m *memMongo) DBFindAndRemoveCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	ci, err := m.findAndModify(ctx, d, c, ret, query, mgo.Change{Remove: true})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	removed = ci.Removed
+	return
+}
+func (m *memMongo) DBFindAndUpdateRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	if err = m.checkUpdate(update); err != nil {
+		return
+	}
+	ci, err := m.findAndModify(ctx, d, c, ret, query, mgo.Change{Update: update, ReturnNew: true})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	updated = ci.Updated
+	return
+}
+func (m *memMongo) DBFindAndUpsertRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	if err = m.checkUpdate(upsert); err != nil {
+		return
+	}
+	ci, err := m.findAndModify(ctx, d, c, ret, query, mgo.Change{Update: upsert, Upsert: true, ReturnNew: true})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	upsertedId = ci.UpsertedId
+	return
+}
+
+func (m *memMongo) DBInsertCtx(ctx context.Context, d string, c string, docs ...interface{}) (err error) {
+	return m.write(ctx, d, c, func(cl *memCollection) error {
+		return m.insert(cl, docs)
+	})
+}
+func (m *memMongo) DBRemoveOneCtx(ctx context.Context, d string, c string, selector interface{}) (ok bool, err error) {
+	var n int
+	err = m.write(ctx, d, c, func(cl *memCollection) (err error) {
+		n, err = m.remove(cl, selector, false)
+		return
+	})
+	ok = err == nil && n > 0
+	return
+}
+func (m *memMongo) DBRemoveAllCtx(ctx context.Context, d string, c string, selector interface{}) (removed int, err error) {
+	err = m.write(ctx, d, c, func(cl *memCollection) (err error) {
+		removed, err = m.remove(cl, selector, true)
+		return
+	})
+	return
+}
+func (m *memMongo) DBRemoveIdCtx(ctx context.Context, d string, c string, id interface{}) (ok bool, err error) {
+	return m.DBRemoveOneCtx(ctx, d, c, bson.M{"_id": id})
+}
+func (m *memMongo) DBUpdateOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (ok bool, err error) {
+	if err = m.checkUpdate(update); err != nil {
+		return
+	}
+	var ci *mgo.ChangeInfo
+	err = m.write(ctx, d, c, func(cl *memCollection) (err error) {
+		ci, err = m.update(cl, selector, update, false, false)
+		return
+	})
+	ok = err == nil && ci.Matched > 0
+	return
+}
+func (m *memMongo) DBUpdateAllCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	if err = m.checkUpdate(update); err != nil {
+		return
+	}
+	err = m.write(ctx, d, c, func(cl *memCollection) error {
+		ci, err := m.update(cl, selector, update, true, false)
+		if err != nil {
+			return err
+		}
+		updated = ci.Updated
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBUpdateIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (ok bool, err error) {
+	return m.DBUpdateOneCtx(ctx, d, c, bson.M{"_id": id}, update)
+}
+
+// DBUpsertOneCtx 与gsSession一致, upsertId返回*mgo.ChangeInfo
+func (m *memMongo) DBUpsertOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (upsertId interface{}, err error) {
+	if err = m.checkUpdate(update); err != nil {
+		return
+	}
+	err = m.write(ctx, d, c, func(cl *memCollection) error {
+		ci, err := m.update(cl, selector, update, false, true)
+		if err != nil {
+			return err
+		}
+		upsertId = ci
+		return nil
+	})
+	return
+}
+func (m *memMongo) DBUpsertIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (upsertId interface{}, err error) {
+	return m.DBUpsertOneCtx(ctx, d, c, bson.M{"_id": id}, update)
+}
+func (m *memMongo) DBRunBulkCtx(ctx context.Context, d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	bk := &memBulk{m: m}
+	f(bk, args...)
+	err = m.write(ctx, d, c, func(cl *memCollection) error {
+		var rm, rn int
+		for _, op := range bk.ops {
+			mt, md, err := op(cl)
+			if err != nil {
+				return err
+			}
+			rm += mt
+			rn += md
+		}
+		matched, modified = rm, rn
+		return nil
+	})
+	return
+}
+
+func (m *memMongo) DBRunCollectionCtx(ctx context.Context, d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return nil, ErrNotSupported
+}
+
+func (m *memMongo) RunSessionCtx(ctx context.Context, f SessionFunc, args ...interface{}) (interface{}, error) {
+	return nil, ErrNotSupported
+}
+
+func (m *memMongo) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.life.acquire(); err != nil {
+		return err
+	}
+	m.life.release()
+	return nil
+}
+
+// Close 拒绝新调用并等待进行中的调用结束, 数据保留
+func (m *memMongo) Close() error {
+	<-m.life.shutdown()
+	return nil
+}
+
+// memIter 迭代创建时的结果快照, batch无意义
+type memIter struct {
+	ctx  context.Context
+	docs []bson.M
+	err  error
+}
+
+func (it *memIter) Next(ret interface{}) bool {
+	if it.err != nil || len(it.docs) == 0 {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	if it.err = decodeDoc(it.docs[0], ret); it.err != nil {
+		return false
+	}
+	it.docs = it.docs[1:]
+	return true
+}
+func (it *memIter) Err() error {
+	return it.err
+}
+func (it *memIter) Close() error {
+	it.docs = nil
+	return it.err
+}
+
+// read 在读锁下访问集合, 集合不存在时cl为nil
+func (m *memMongo) read(ctx context.Context, d string, c string, f func(cl *memCollection) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.life.acquire(); err != nil {
+		return err
+	}
+	defer m.life.release()
+	m.RLock()
+	defer m.RUnlock()
+
+	return f(m.dbs[d][c])
+}
+
+// admin 在锁下访问所有数据库, 用于集合及数据库管理
+func (m *memMongo) admin(ctx context.Context, write bool, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.life.acquire(); err != nil {
+		return err
+	}
+	defer m.life.release()
+	if write {
+		m.Lock()
+		defer m.Unlock()
+	} else {
+		m.RLock()
+		defer m.RUnlock()
+	}
+	return f()
+}
+
+// collection 返回集合, 不存在时创建. 调用方须持有写锁
+func (m *memMongo) collection(d string, c string) *memCollection {
+	db, ok := m.dbs[d]
+	if !ok {
+		db = make(map[string]*memCollection)
+		m.dbs[d] = db
+	}
+	cl, ok := db[c]
+	if !ok {
+		cl = new(memCollection)
+		db[c] = cl
+	}
+	return cl
+}
+
+// write 在写锁下访问集合, 集合不存在时自动创建
+func (m *memMongo) write(ctx context.Context, d string, c string, f func(cl *memCollection) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.life.acquire(); err != nil {
+		return err
+	}
+	defer m.life.release()
+	m.Lock()
+	defer m.Unlock()
+
+	return f(m.collection(d, c))
+}
+
+// stats 以文档的BSON大小估算collStats, 集合不存在时为零值
+func (cl *memCollection) stats() *CollStats {
+	stats := &CollStats{Nindexes: 1, IndexSizes: map[string]int64{"_id_": 0}}
+	if cl == nil {
+		return stats
+	}
+	stats.Count = len(cl.docs)
+	for _, doc := range cl.docs {
+		if data, err := bson.Marshal(doc); err == nil {
+			stats.Size += int64(len(data))
+		}
+	}
+	if stats.Count > 0 {
+		stats.AvgObjSize = stats.Size / int64(stats.Count)
+	}
+	stats.StorageSize = stats.Size
+	for _, idx := range cl.indexes {
+		stats.IndexSizes[idx.Name] = 0
+	}
+	stats.Nindexes += len(cl.indexes)
+	if info := cl.info; info != nil && info.Capped {
+		stats.Capped, stats.Max, stats.MaxSize = true, int64(info.MaxDocs), int64(info.MaxBytes)
+	}
+	return stats
+}
+
+// match 返回集合中匹配query的文档下标
+func (cl *memCollection) match(query interface{}) ([]int, error) {
+	if cl == nil {
+		return nil, nil
+	}
+	q, err := toDoc(query)
+	if err != nil {
+		return nil, err
+	}
+	var idx []int
+	for i, doc := range cl.docs {
+		ok, err := matchDoc(doc, q)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			idx = append(idx, i)
+		}
+	}
+	return idx, nil
+}
+
+// sorted 返回匹配query并按sort排序的文档
+func (cl *memCollection) sorted(query interface{}, sort []string) ([]bson.M, error) {
+	keys, err := parseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := cl.match(query)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]bson.M, len(idx))
+	for i, j := range idx {
+		docs[i] = cl.docs[j]
+	}
+	sortDocs(docs, keys)
+	return docs, nil
+}
+
+func (cl *memCollection) aggregate(pipeline interface{}) ([]bson.M, error) {
+	stages, err := parsePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.M
+	if cl != nil {
+		docs = cl.docs
+	}
+	return runPipeline(docs, stages)
+}
+
+func (m *memMongo) findOne(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (ok bool, err error) {
+	if plan := explainPlan(ctx); plan != nil {
+		return false, m.explain(ctx, d, c, plan, query, nil)
+	}
+	err = m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.sorted(query, nil)
+		if err != nil || len(docs) == 0 {
+			return err
+		}
+		doc, err := projectDoc(docs[0], projection)
+		if err != nil {
+			return err
+		}
+		ok = true
+		return decodeDoc(doc, ret)
+	})
+	return
+}
+
+func (m *memMongo) findAll(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort []string) error {
+	if plan := explainPlan(ctx); plan != nil {
+		if tot != nil {
+			*tot = 0
+		}
+		return m.explain(ctx, d, c, plan, query, sort)
+	}
+	return m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.sorted(query, sort)
+		if err != nil {
+			return err
+		}
+		if tot != nil {
+			*tot = uint32(len(docs))
+		}
+		if int(skip) >= len(docs) {
+			docs = nil
+		} else {
+			docs = docs[skip:]
+		}
+		if limit > 0 && int(limit) < len(docs) {
+			docs = docs[:limit]
+		}
+		vals := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			if vals[i], err = projectDoc(doc, projection); err != nil {
+				return err
+			}
+		}
+		return decodeValues(vals, ret)
+	})
+}
+
+func (m *memMongo) distinct(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string) error {
+	if plan := explainPlan(ctx); plan != nil {
+		return m.explain(ctx, d, c, plan, query, nil)
+	}
+	return m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.sorted(query, nil)
+		if err != nil {
+			return err
+		}
+		var vals []interface{}
+		for _, doc := range docs {
+			for _, v := range expandValues(lookupPath(doc, key)) {
+				if _, isArr := v.([]interface{}); !isArr && !containsValue(vals, v) {
+					vals = append(vals, v)
+				}
+			}
+		}
+		return decodeValues(vals, ret)
+	})
+}
+
+func explainPlan(ctx context.Context) *Plan {
+	if o := callOptionsFrom(ctx); o != nil {
+		return o.explain
+	}
+	return nil
+}
+
+// explain 内存实现按索引前缀模拟查询计划, 实际执行总是全表扫描
+func (m *memMongo) explain(ctx context.Context, d string, c string, plan *Plan, query interface{}, sort []string) error {
+	keys, err := parseSort(sort)
+	if err != nil {
+		return err
+	}
+	return m.read(ctx, d, c, func(cl *memCollection) error {
+		idx, err := cl.match(query)
+		if err != nil {
+			return err
+		}
+		return cl.plan(plan, query, keys, len(idx))
+	})
+}
+
+// explainPipe 开头的$match及紧随的$sort参与选择索引, 其它$sort在内存中排序
+func (m *memMongo) explainPipe(ctx context.Context, d string, c string, plan *Plan, pipeline interface{}) error {
+	stages, err := parsePipeline(pipeline)
+	if err != nil {
+		return err
+	}
+	return m.read(ctx, d, c, func(cl *memCollection) error {
+		docs, err := cl.aggregate(pipeline)
+		if err != nil {
+			return err
+		}
+		var query interface{}
+		var keys []sortKey
+		rest := stages
+		if len(rest) > 0 && len(rest[0]) == 1 && rest[0][0].Name == "$match" {
+			query, rest = cloneValue(rest[0][0].Value), rest[1:]
+		}
+		if len(rest) > 0 && len(rest[0]) == 1 && rest[0][0].Name == "$sort" {
+			spec, _ := rest[0][0].Value.(bson.D)
+			for _, e := range spec {
+				n, _ := toInt64(e.Value)
+				keys = append(keys, sortKey{field: e.Name, desc: n < 0})
+			}
+			rest = rest[1:]
+		}
+		if err = cl.plan(plan, query, keys, len(docs)); err != nil {
+			return err
+		}
+		for _, stage := range rest {
+			if len(stage) == 1 && stage[0].Name == "$sort" {
+				plan.InMemorySort = true
+			}
+		}
+		return nil
+	})
+}
+
+// plan 选择前缀字段出现在query顶层或顺序满足sort的索引, 前缀字段多者优先, 没有可用索引时为全表扫描
+func (cl *memCollection) plan(plan *Plan, query interface{}, sort []sortKey, returned int) error {
+	*plan = Plan{Stage: "COLLSCAN", Stages: []string{"COLLSCAN"}, Returned: returned}
+	sorted := false
+	if cl != nil {
+		q, err := toDoc(query)
+		if err != nil {
+			return err
+		}
+		var index *mgo.Index
+		var prefix []sortKey
+		best := 0
+		indexes := append([]mgo.Index{{Name: "_id_", Key: []string{"_id"}}}, cl.indexes...)
+		for i := range indexes {
+			keys, err := parseSort(indexes[i].Key)
+			if err != nil {
+				continue
+			}
+			n := 0
+			for ; n < len(keys); n++ {
+				if _, ok := q[keys[n].field]; !ok {
+					break
+				}
+			}
+			ok := len(sort) > 0 && sortByIndex(keys, n, sort)
+			if score := 2*n + boolCount(ok); score > best {
+				best, index, prefix, sorted = score, &indexes[i], keys[:n], ok
+			}
+		}
+		plan.DocsExamined = len(cl.docs)
+		if index != nil {
+			plan.Stage, plan.Stages, plan.Index = "IXSCAN", []string{"FETCH", "IXSCAN"}, index.Name
+			if len(prefix) > 0 {
+				bound := bson.M{}
+				for _, k := range prefix {
+					bound[k.field] = q[k.field]
+				}
+				idx, err := cl.match(bound)
+				if err != nil {
+					return err
+				}
+				plan.DocsExamined = len(idx)
+			}
+			plan.KeysExamined = plan.DocsExamined
+		}
+	}
+	if len(sort) > 0 && !sorted {
+		plan.Stages = append([]string{"SORT"}, plan.Stages...)
+		plan.InMemorySort = true
+	}
+	return nil
+}
+
+// sortByIndex 跳过最多eq个前缀字段后, 索引字段的顺序(或整体反向)与sort一致
+func sortByIndex(keys []sortKey, eq int, sort []sortKey) bool {
+	for k := 0; k <= eq && k+len(sort) <= len(keys); k++ {
+		same, reverse := true, true
+		for i, s := range sort {
+			if keys[k+i].field != s.field {
+				same, reverse = false, false
+				break
+			}
+			if keys[k+i].desc == s.desc {
+				reverse = false
+			} else {
+				same = false
+			}
+		}
+		if same || reverse {
+			return true
+		}
+	}
+	return false
+}
+
+// findAndModify 语义与mgo的Query.Apply一致: 无匹配且非upsert时返回mgo.ErrNotFound
+func (m *memMongo) findAndModify(ctx context.Context, d string, c string, ret interface{}, query interface{}, change mgo.Change) (info *mgo.ChangeInfo, err error) {
+	err = m.write(ctx, d, c, func(cl *memCollection) error {
+		idx, err := cl.match(query)
+		if err != nil {
+			return err
+		}
+		info = &mgo.ChangeInfo{}
+		var doc bson.M
+		switch {
+		case len(idx) == 0 && !change.Upsert:
+			return mgo.ErrNotFound
+		case change.Remove:
+			doc = cl.docs[idx[0]]
+			cl.docs = append(cl.docs[:idx[0]], cl.docs[idx[0]+1:]...)
+			info.Removed, info.Matched = 1, 1
+		case len(idx) == 0:
+			if doc, err = m.upsertDoc(cl, query, change.Update); err != nil {
+				return err
+			}
+			info.UpsertedId = doc["_id"]
+			if !change.ReturnNew {
+				return nil
+			}
+		default:
+			old := cl.docs[idx[0]]
+			if doc, err = m.replace(cl, idx[0], change.Update); err != nil {
+				return err
+			}
+			info.Updated, info.Matched = 1, 1
+			if !change.ReturnNew {
+				doc = old
+			}
+		}
+		if ret != nil {
+			return decodeDoc(doc, ret)
+		}
+		return nil
+	})
+	return
+}
+
+func (m *memMongo) insert(cl *memCollection, docs []interface{}) error {
+	for _, d := range docs {
+		doc, err := toDoc(d)
+		if err != nil {
+			return err
+		}
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = bson.NewObjectId()
+		}
+		if err = cl.checkUnique(doc, -1); err != nil {
+			return err
+		}
+		cl.docs = append(cl.docs, doc)
+	}
+	return nil
+}
+
+func (m *memMongo) remove(cl *memCollection, selector interface{}, multi bool) (int, error) {
+	idx, err := cl.match(selector)
+	if err != nil {
+		return 0, err
+	}
+	if !multi && len(idx) > 1 {
+		idx = idx[:1]
+	}
+	for i := len(idx) - 1; i >= 0; i-- {
+		cl.docs = append(cl.docs[:idx[i]], cl.docs[idx[i]+1:]...)
+	}
+	return len(idx), nil
+}
+
+// update 语义与mgo的Update/UpdateAll/Upsert一致, Updated为实际修改的文档数
+func (m *memMongo) update(cl *memCollection, selector interface{}, update interface{}, multi bool, upsert bool) (*mgo.ChangeInfo, error) {
+	idx, err := cl.match(selector)
+	if err != nil {
+		return nil, err
+	}
+	info := &mgo.ChangeInfo{}
+	if len(idx) == 0 {
+		if upsert {
+			doc, err := m.upsertDoc(cl, selector, update)
+			if err != nil {
+				return nil, err
+			}
+			info.UpsertedId = doc["_id"]
+		}
+		return info, nil
+	}
+	if !multi {
+		idx = idx[:1]
+	}
+	for _, i := range idx {
+		old := cl.docs[i]
+		doc, err := m.replace(cl, i, update)
+		if err != nil {
+			return nil, err
+		}
+		info.Matched++
+		if !equalValues(old, doc) {
+			info.Updated++
+		}
+	}
+	return info, nil
+}
+
+// replace 对第i个文档执行更新并检查唯一索引
+func (m *memMongo) replace(cl *memCollection, i int, update interface{}) (bson.M, error) {
+	up, err := toDoc(update)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := applyUpdate(cl.docs[i], up, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = cl.checkUnique(doc, i); err != nil {
+		return nil, err
+	}
+	cl.docs[i] = doc
+	return doc, nil
+}
+
+func (m *memMongo) upsertDoc(cl *memCollection, selector interface{}, update interface{}) (bson.M, error) {
+	q, err := toDoc(selector)
+	if err != nil {
+		return nil, err
+	}
+	up, err := toDoc(update)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := upsertSeed(q)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := applyUpdate(seed, up, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = bson.NewObjectId()
+	}
+	if err = cl.checkUnique(doc, -1); err != nil {
+		return nil, err
+	}
+	cl.docs = append(cl.docs, doc)
+	return doc, nil
+}
+
+// checkUnique 检查doc是否违反_id及唯一索引约束, skip为被替换文档的下标
+func (cl *memCollection) checkUnique(doc bson.M, skip int) error {
+	others := make([]bson.M, 0, len(cl.docs))
+	for i, d := range cl.docs {
+		if i != skip {
+			others = append(others, d)
+		}
+	}
+	if err := checkUnique(others, doc, mgo.Index{Name: "_id_", Key: []string{"_id"}, Unique: true}); err != nil {
+		return err
+	}
+	for _, idx := range cl.indexes {
+		if idx.Unique {
+			if err := checkUnique(others, doc, idx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkUnique(docs []bson.M, doc bson.M, index mgo.Index) error {
+	keys, err := parseSort(index.Key)
+	if err != nil {
+		return err
+	}
+	for _, other := range docs {
+		dup := true
+		for _, k := range keys {
+			a, _ := getPath(doc, k.field)
+			b, _ := getPath(other, k.field)
+			if !equalValues(a, b) {
+				dup = false
+				break
+			}
+		}
+		if dup {
+			return &mgo.LastError{Code: 11000, Err: fmt.Sprintf("E11000 duplicate key error index: %s dup key: %v", index.Name, doc["_id"])}
+		}
+	}
+	return nil
+}
+
+// indexName 与mgo生成索引名规则一致, 如[]string{"-a", "b"}为"a_-1_b_1"
+func indexName(key []string) (string, error) {
+	keys, err := parseSort(key)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", errors.New("invalid index key: no fields provided")
+	}
+	name := ""
+	for i, k := range keys {
+		if i > 0 {
+			name += "_"
+		}
+		if k.desc {
+			name += k.field + "_-1"
+		} else {
+			name += k.field + "_1"
+		}
+	}
+	return name, nil
+}
+
+func projectDoc(doc bson.M, projection interface{}) (bson.M, error) {
+	if projection == nil {
+		return doc, nil
+	}
+	p, err := toDoc(projection)
+	if err != nil {
+		return nil, err
+	}
+	return project(doc, p)
+}
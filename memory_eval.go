@@ -0,0 +1,1611 @@
+package mongo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+内存实现的求值逻辑: 查询匹配, 更新操作, 投影, 排序及BSON值比较.
+文档统一经过bson编解码归一化为bson.M, 嵌套文档为bson.M, 数组为[]interface{}.
+*/
+
+// toDoc 将任意文档(bson.M, bson.D, map, struct)归一化为bson.M
+func toDoc(v interface{}) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// cloneDoc 深拷贝文档, 同时将bson.D/map归一化为bson.M
+func cloneDoc(doc bson.M) bson.M {
+	ret := make(bson.M, len(doc))
+	for k, v := range doc {
+		ret[k] = cloneValue(v)
+	}
+	return ret
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.M:
+		return cloneDoc(v)
+	case map[string]interface{}:
+		return cloneDoc(bson.M(v))
+	case bson.D:
+		doc := make(bson.M, len(v))
+		for _, e := range v {
+			doc[e.Name] = cloneValue(e.Value)
+		}
+		return doc
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = cloneValue(e)
+		}
+		return arr
+	case []bson.M:
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = cloneDoc(e)
+		}
+		return arr
+	case []byte:
+		return append([]byte(nil), v...)
+	}
+	return v
+}
+
+// decodeDoc 以mgo相同的bson解码规则将文档写入ret
+func decodeDoc(doc bson.M, ret interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, ret)
+}
+
+// decodeValues 将值列表写入ret指向的slice, 与mgo的Iter.All/Distinct行为一致
+func decodeValues(vals []interface{}, ret interface{}) error {
+	data, err := bson.Marshal(bson.M{"values": vals})
+	if err != nil {
+		return err
+	}
+	var doc struct {
+		Values bson.Raw
+	}
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return doc.Values.Unmarshal(ret)
+}
+
+/*-------------------------------- 路径访问 --------------------------------*/
+
+// lookupPath 返回路径对应的所有值, 中间遇到数组时展开到每个元素
+func lookupPath(v interface{}, path string) []interface{} {
+	return lookupParts(v, strings.Split(path, "."))
+}
+
+func lookupParts(v interface{}, parts []string) []interface{} {
+	if len(parts) == 0 {
+		return []interface{}{v}
+	}
+	switch v := v.(type) {
+	case bson.M:
+		if fv, ok := v[parts[0]]; ok {
+			return lookupParts(fv, parts[1:])
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(parts[0]); err == nil {
+			if i >= 0 && i < len(v) {
+				return lookupParts(v[i], parts[1:])
+			}
+			return nil
+		}
+		var ret []interface{}
+		for _, e := range v {
+			if _, ok := e.(bson.M); ok {
+				ret = append(ret, lookupParts(e, parts)...)
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+// getPath 返回路径对应的单个值, 不展开数组
+func getPath(doc bson.M, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, p := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case bson.M:
+			fv, ok := v[p]
+			if !ok {
+				return nil, false
+			}
+			cur = fv
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setPath 设置路径对应的值, 缺失的中间文档自动创建
+func setPath(doc bson.M, path string, val interface{}) error {
+	parts := strings.Split(path, ".")
+	var cur interface{} = doc
+	for i, p := range parts {
+		last := i == len(parts)-1
+		switch v := cur.(type) {
+		case bson.M:
+			if last {
+				v[p] = val
+				return nil
+			}
+			next, ok := v[p]
+			if !ok || next == nil {
+				next = bson.M{}
+				v[p] = next
+			}
+			cur = next
+		case []interface{}:
+			idx, err := strconv.Atoi(p)
+			if err != nil || idx < 0 {
+				return fmt.Errorf("cannot use the part (%s) of (%s) to traverse the element", p, path)
+			}
+			if idx >= len(v) {
+				return fmt.Errorf("cannot set array element %d of (%s) out of range", idx, path)
+			}
+			if last {
+				v[idx] = val
+				return nil
+			}
+			if v[idx] == nil {
+				v[idx] = bson.M{}
+			}
+			cur = v[idx]
+		default:
+			return fmt.Errorf("cannot create field '%s' in element {%v}", p, v)
+		}
+	}
+	return nil
+}
+
+// unsetPath 删除路径对应的字段. 数组元素置为null, 与mongo行为一致
+func unsetPath(doc bson.M, path string) {
+	parts := strings.Split(path, ".")
+	var cur interface{} = doc
+	for i, p := range parts {
+		last := i == len(parts)-1
+		switch v := cur.(type) {
+		case bson.M:
+			if last {
+				delete(v, p)
+				return
+			}
+			cur = v[p]
+		case []interface{}:
+			idx, err := strconv.Atoi(p)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return
+			}
+			if last {
+				v[idx] = nil
+				return
+			}
+			cur = v[idx]
+		default:
+			return
+		}
+	}
+}
+
+/*-------------------------------- 值比较 --------------------------------*/
+
+// bsonOrder 返回mongo的类型比较顺序
+func bsonOrder(v interface{}) int {
+	switch v {
+	case bson.MinKey:
+		return 0
+	case bson.Undefined:
+		return 1
+	case bson.MaxKey:
+		return 100
+	}
+	switch v.(type) {
+	case nil:
+		return 1
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+		return 2
+	case string, bson.Symbol:
+		return 3
+	case bson.M, bson.D, map[string]interface{}:
+		return 4
+	case []interface{}:
+		return 5
+	case []byte, bson.Binary:
+		return 6
+	case bson.ObjectId:
+		return 7
+	case bool:
+		return 8
+	case time.Time:
+		return 9
+	case bson.MongoTimestamp:
+		return 10
+	case bson.RegEx:
+		return 11
+	}
+	return 50
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func sign(b bool) int {
+	if b {
+		return 1
+	}
+	return -1
+}
+
+// compareValues 按mongo的BSON比较规则比较两个值
+func compareValues(a, b interface{}) int {
+	oa, ob := bsonOrder(a), bsonOrder(b)
+	if oa != ob {
+		return sign(oa > ob)
+	}
+	if oa == 0 || oa == 1 || oa == 100 {
+		return 0
+	}
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, toString(b))
+	case bson.Symbol:
+		return strings.Compare(string(av), toString(b))
+	case bson.ObjectId:
+		return strings.Compare(string(av), string(b.(bson.ObjectId)))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		return sign(av)
+	case time.Time:
+		bv := b.(time.Time)
+		if av.Equal(bv) {
+			return 0
+		}
+		return sign(av.After(bv))
+	case bson.MongoTimestamp:
+		bv := b.(bson.MongoTimestamp)
+		if av == bv {
+			return 0
+		}
+		return sign(av > bv)
+	case bson.RegEx:
+		bv := b.(bson.RegEx)
+		if n := strings.Compare(av.Pattern, bv.Pattern); n != 0 {
+			return n
+		}
+		return strings.Compare(av.Options, bv.Options)
+	case []interface{}:
+		bv := b.([]interface{})
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if n := compareValues(av[i], bv[i]); n != 0 {
+				return n
+			}
+		}
+		if len(av) == len(bv) {
+			return 0
+		}
+		return sign(len(av) > len(bv))
+	case []byte, bson.Binary:
+		return bytes.Compare(toBytes(a), toBytes(b))
+	}
+	if oa == 2 {
+		ai, aok := toInt64(a)
+		bi, bok := toInt64(b)
+		if aok && bok {
+			if ai == bi {
+				return 0
+			}
+			return sign(ai > bi)
+		}
+		af, bf := toFloat(a), toFloat(b)
+		if af == bf {
+			return 0
+		}
+		return sign(af > bf)
+	}
+	if oa == 4 {
+		ad, bd := asDoc(a), asDoc(b)
+		ak, bk := sortedKeys(ad), sortedKeys(bd)
+		for i := 0; i < len(ak) && i < len(bk); i++ {
+			if n := strings.Compare(ak[i], bk[i]); n != 0 {
+				return n
+			}
+			if n := compareValues(ad[ak[i]], bd[bk[i]]); n != 0 {
+				return n
+			}
+		}
+		if len(ak) == len(bk) {
+			return 0
+		}
+		return sign(len(ak) > len(bk))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func asDoc(v interface{}) bson.M {
+	if doc, ok := v.(bson.M); ok {
+		return doc
+	}
+	doc, _ := toDoc(v)
+	return doc
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bson.Symbol:
+		return string(v)
+	}
+	return ""
+}
+
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case bson.Binary:
+		return v.Data
+	}
+	return nil
+}
+
+func sortedKeys(doc bson.M) []string {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalValues(a, b interface{}) bool {
+	return compareValues(a, b) == 0
+}
+
+func isTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	if bsonOrder(v) == 2 {
+		return toFloat(v) != 0
+	}
+	return true
+}
+
+/*-------------------------------- 查询匹配 --------------------------------*/
+
+func isOperatorDoc(v interface{}) (bson.M, bool) {
+	doc, ok := v.(bson.M)
+	if !ok || len(doc) == 0 {
+		return nil, false
+	}
+	for k := range doc {
+		if !strings.HasPrefix(k, "$") {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// expandValues 展开数组元素, 数组字段匹配其任一元素或整个数组
+func expandValues(vals []interface{}) []interface{} {
+	ret := make([]interface{}, 0, len(vals))
+	for _, v := range vals {
+		ret = append(ret, v)
+		if arr, ok := v.([]interface{}); ok {
+			ret = append(ret, arr...)
+		}
+	}
+	return ret
+}
+
+func toList(v interface{}) ([]interface{}, error) {
+	if arr, ok := v.([]interface{}); ok {
+		return arr, nil
+	}
+	return nil, fmt.Errorf("expected an array but got %T", v)
+}
+
+func matchDoc(doc bson.M, query bson.M) (bool, error) {
+	for k, v := range query {
+		switch k {
+		case "$and", "$or", "$nor":
+			list, err := toList(v)
+			if err != nil {
+				return false, fmt.Errorf("%s: %v", k, err)
+			}
+			if len(list) == 0 {
+				return false, errors.New(k + " must be a nonempty array")
+			}
+			hit := 0
+			for _, e := range list {
+				sub, ok := e.(bson.M)
+				if !ok {
+					return false, errors.New(k + " entries need to be full objects")
+				}
+				ok, err := matchDoc(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					hit++
+				}
+			}
+			switch {
+			case k == "$and" && hit != len(list),
+				k == "$or" && hit == 0,
+				k == "$nor" && hit > 0:
+				return false, nil
+			}
+		case "$comment":
+		default:
+			if strings.HasPrefix(k, "$") {
+				return false, errors.New("unknown top level operator: " + k)
+			}
+			ok, err := matchField(doc, k, v)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchField(doc bson.M, path string, cond interface{}) (bool, error) {
+	vals := lookupPath(doc, path)
+	if ops, ok := isOperatorDoc(cond); ok {
+		for op, arg := range ops {
+			if op == "$options" {
+				if _, ok := ops["$regex"]; !ok {
+					return false, errors.New("$options needs a $regex")
+				}
+				continue
+			}
+			ok, err := matchOp(vals, op, arg, ops)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if re, ok := cond.(bson.RegEx); ok {
+		return matchRegex(vals, re)
+	}
+	return matchEq(vals, cond), nil
+}
+
+func matchEq(vals []interface{}, cond interface{}) bool {
+	if cond == nil && len(vals) == 0 {
+		return true
+	}
+	for _, v := range expandValues(vals) {
+		if equalValues(v, cond) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(vals []interface{}, arg interface{}, f func(n int) bool) bool {
+	for _, v := range expandValues(vals) {
+		if bsonOrder(v) == bsonOrder(arg) && f(compareValues(v, arg)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileRegex(re bson.RegEx) (*regexp.Regexp, error) {
+	flags := ""
+	for _, o := range re.Options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		}
+	}
+	if flags != "" {
+		return regexp.Compile("(?" + flags + ")" + re.Pattern)
+	}
+	return regexp.Compile(re.Pattern)
+}
+
+func matchRegex(vals []interface{}, re bson.RegEx) (bool, error) {
+	rx, err := compileRegex(re)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range expandValues(vals) {
+		switch v := v.(type) {
+		case string:
+			if rx.MatchString(v) {
+				return true, nil
+			}
+		case bson.Symbol:
+			if rx.MatchString(string(v)) {
+				return true, nil
+			}
+		case bson.RegEx:
+			if v == re {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func matchIn(vals []interface{}, arg interface{}) (bool, error) {
+	list, err := toList(arg)
+	if err != nil {
+		return false, fmt.Errorf("$in needs an array")
+	}
+	for _, e := range list {
+		if re, ok := e.(bson.RegEx); ok {
+			if ok, err := matchRegex(vals, re); err != nil || ok {
+				return ok, err
+			}
+		} else if matchEq(vals, e) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchOp(vals []interface{}, op string, arg interface{}, ops bson.M) (bool, error) {
+	switch op {
+	case "$eq":
+		return matchEq(vals, arg), nil
+	case "$ne":
+		return !matchEq(vals, arg), nil
+	case "$gt":
+		return matchCompare(vals, arg, func(n int) bool { return n > 0 }), nil
+	case "$gte":
+		return matchCompare(vals, arg, func(n int) bool { return n >= 0 }), nil
+	case "$lt":
+		return matchCompare(vals, arg, func(n int) bool { return n < 0 }), nil
+	case "$lte":
+		return matchCompare(vals, arg, func(n int) bool { return n <= 0 }), nil
+	case "$in":
+		return matchIn(vals, arg)
+	case "$nin":
+		ok, err := matchIn(vals, arg)
+		return !ok, err
+	case "$exists":
+		return isTruthy(arg) == (len(vals) > 0), nil
+	case "$regex":
+		re := bson.RegEx{}
+		switch arg := arg.(type) {
+		case string:
+			re.Pattern = arg
+		case bson.RegEx:
+			re = arg
+		default:
+			return false, errors.New("$regex has to be a string")
+		}
+		if opts, ok := ops["$options"].(string); ok {
+			re.Options = opts
+		}
+		return matchRegex(vals, re)
+	case "$not":
+		switch arg := arg.(type) {
+		case bson.RegEx:
+			ok, err := matchRegex(vals, arg)
+			return !ok, err
+		case bson.M:
+			for sop, sarg := range arg {
+				ok, err := matchOp(vals, sop, sarg, arg)
+				if err != nil {
+					return false, err
+				}
+				if !ok {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return false, errors.New("$not needs a regex or a document")
+	case "$size":
+		n, ok := toInt64(arg)
+		if !ok {
+			return false, errors.New("$size needs a number")
+		}
+		for _, v := range vals {
+			if arr, ok := v.([]interface{}); ok && int64(len(arr)) == n {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$all":
+		list, err := toList(arg)
+		if err != nil {
+			return false, errors.New("$all needs an array")
+		}
+		if len(list) == 0 {
+			return false, nil
+		}
+		for _, e := range list {
+			if !matchEq(vals, e) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$elemMatch":
+		cond, ok := arg.(bson.M)
+		if !ok {
+			return false, errors.New("$elemMatch needs an Object")
+		}
+		for _, v := range vals {
+			arr, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, e := range arr {
+				var ok bool
+				var err error
+				if ops, isOps := isOperatorDoc(cond); isOps {
+					ok, err = matchField(bson.M{"v": e}, "v", ops)
+				} else if sub, isDoc := e.(bson.M); isDoc {
+					ok, err = matchDoc(sub, cond)
+				}
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	return false, errors.New("unknown operator: " + op)
+}
+
+/*-------------------------------- 更新操作 --------------------------------*/
+
+// isReplacement 判断update是整体替换文档还是操作符文档
+func isReplacement(update bson.M) (bool, error) {
+	ops := 0
+	for k := range update {
+		if strings.HasPrefix(k, "$") {
+			ops++
+		}
+	}
+	if ops > 0 && ops != len(update) {
+		return false, errors.New("update document mixes operators and fields")
+	}
+	return ops == 0, nil
+}
+
+// upsertSeed 由查询条件中的等值部分构造upsert的初始文档
+func upsertSeed(query bson.M) (bson.M, error) {
+	doc := bson.M{}
+	var seed func(q bson.M) error
+	seed = func(q bson.M) error {
+		for k, v := range q {
+			if k == "$and" {
+				list, _ := v.([]interface{})
+				for _, e := range list {
+					if sub, ok := e.(bson.M); ok {
+						if err := seed(sub); err != nil {
+							return err
+						}
+					}
+				}
+				continue
+			}
+			if strings.HasPrefix(k, "$") {
+				continue
+			}
+			if ops, ok := isOperatorDoc(v); ok {
+				if eq, ok := ops["$eq"]; ok {
+					if err := setPath(doc, k, cloneValue(eq)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if _, ok := v.(bson.RegEx); ok {
+				continue
+			}
+			if err := setPath(doc, k, cloneValue(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return doc, seed(query)
+}
+
+// applyUpdate 对文档执行更新, 返回新文档. insert表示upsert插入, 此时$setOnInsert生效
+func applyUpdate(doc bson.M, update bson.M, insert bool) (bson.M, error) {
+	replace, err := isReplacement(update)
+	if err != nil {
+		return nil, err
+	}
+	if replace {
+		ret := cloneDoc(update)
+		if id, ok := doc["_id"]; ok {
+			if rid, ok := ret["_id"]; ok && !equalValues(id, rid) {
+				return nil, errors.New("the _id field cannot be changed")
+			}
+			ret["_id"] = id
+		}
+		return ret, nil
+	}
+
+	ret := cloneDoc(doc)
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return nil, fmt.Errorf("modifier %s allowed for objects only", op)
+		}
+		for path, v := range fields {
+			if path == "_id" && op != "$setOnInsert" && !(op == "$set" && insert) {
+				if cur, ok := ret["_id"]; !ok || !equalValues(cur, v) {
+					return nil, errors.New("performing an update on the path '_id' would modify the immutable field '_id'")
+				}
+			}
+			if err = applyOp(ret, op, path, cloneValue(v), insert); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+func applyOp(doc bson.M, op string, path string, v interface{}, insert bool) error {
+	cur, exists := getPath(doc, path)
+	switch op {
+	case "$set":
+		return setPath(doc, path, v)
+	case "$setOnInsert":
+		if insert {
+			return setPath(doc, path, v)
+		}
+	case "$unset":
+		unsetPath(doc, path)
+	case "$inc", "$mul":
+		if bsonOrder(v) != 2 {
+			return fmt.Errorf("cannot %s with non-numeric argument", op[1:])
+		}
+		if !exists {
+			if op == "$mul" {
+				v = mulNumbers(0, v)
+			}
+			return setPath(doc, path, v)
+		}
+		if bsonOrder(cur) != 2 {
+			return fmt.Errorf("cannot apply %s to a value of non-numeric type", op)
+		}
+		if op == "$inc" {
+			return setPath(doc, path, addNumbers(cur, v))
+		}
+		return setPath(doc, path, mulNumbers(cur, v))
+	case "$min", "$max":
+		if !exists {
+			return setPath(doc, path, v)
+		}
+		n := compareValues(v, cur)
+		if op == "$min" && n < 0 || op == "$max" && n > 0 {
+			return setPath(doc, path, v)
+		}
+	case "$currentDate":
+		now := time.Now()
+		var val interface{} = now.Truncate(time.Millisecond)
+		if spec, ok := v.(bson.M); ok && spec["$type"] == "timestamp" {
+			val = bson.MongoTimestamp(now.Unix() << 32)
+		}
+		return setPath(doc, path, val)
+	case "$rename":
+		to, ok := v.(string)
+		if !ok {
+			return errors.New("the 'to' field for $rename must be a string")
+		}
+		if exists {
+			unsetPath(doc, path)
+			return setPath(doc, to, cur)
+		}
+	case "$push", "$addToSet":
+		arr, err := arrayField(cur, exists, op, path)
+		if err != nil {
+			return err
+		}
+		each := []interface{}{v}
+		if spec, ok := v.(bson.M); ok {
+			if e, ok := spec["$each"]; ok {
+				if each, err = toList(e); err != nil {
+					return fmt.Errorf("the argument to $each in %s must be an array", op)
+				}
+			}
+		}
+		for _, e := range each {
+			if op == "$addToSet" && containsValue(arr, e) {
+				continue
+			}
+			arr = append(arr, e)
+		}
+		if spec, ok := v.(bson.M); ok && op == "$push" {
+			if s, ok := toInt64(spec["$slice"]); ok {
+				arr = sliceArray(arr, int(s))
+			}
+		}
+		return setPath(doc, path, arr)
+	case "$pull", "$pullAll":
+		if !exists {
+			return nil
+		}
+		arr, err := arrayField(cur, exists, op, path)
+		if err != nil {
+			return err
+		}
+		var drop func(e interface{}) (bool, error)
+		if op == "$pullAll" {
+			list, err := toList(v)
+			if err != nil {
+				return errors.New("$pullAll requires an array argument")
+			}
+			drop = func(e interface{}) (bool, error) { return containsValue(list, e), nil }
+		} else if cond, ok := v.(bson.M); ok {
+			drop = func(e interface{}) (bool, error) {
+				if ops, ok := isOperatorDoc(cond); ok {
+					return matchField(bson.M{"v": e}, "v", ops)
+				}
+				if sub, ok := e.(bson.M); ok {
+					return matchDoc(sub, cond)
+				}
+				return false, nil
+			}
+		} else {
+			drop = func(e interface{}) (bool, error) { return equalValues(e, v), nil }
+		}
+		kept := make([]interface{}, 0, len(arr))
+		for _, e := range arr {
+			ok, err := drop(e)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				kept = append(kept, e)
+			}
+		}
+		return setPath(doc, path, kept)
+	case "$pop":
+		if !exists {
+			return nil
+		}
+		arr, err := arrayField(cur, exists, op, path)
+		if err != nil {
+			return err
+		}
+		if len(arr) > 0 {
+			if n, _ := toInt64(v); n < 0 {
+				arr = arr[1:]
+			} else {
+				arr = arr[:len(arr)-1]
+			}
+		}
+		return setPath(doc, path, arr)
+	default:
+		return errors.New("unknown modifier: " + op)
+	}
+	return nil
+}
+
+func arrayField(cur interface{}, exists bool, op string, path string) ([]interface{}, error) {
+	if !exists || cur == nil {
+		return []interface{}{}, nil
+	}
+	arr, ok := cur.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the field '%s' must be an array for %s", path, op)
+	}
+	return append([]interface{}(nil), arr...), nil
+}
+
+func containsValue(arr []interface{}, v interface{}) bool {
+	for _, e := range arr {
+		if equalValues(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sliceArray(arr []interface{}, n int) []interface{} {
+	if n >= 0 {
+		if n < len(arr) {
+			return arr[:n]
+		}
+		return arr
+	}
+	if -n < len(arr) {
+		return arr[len(arr)+n:]
+	}
+	return arr
+}
+
+func isFloat(v interface{}) bool {
+	switch v.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+func isInt64(v interface{}) bool {
+	_, ok := v.(int64)
+	return ok
+}
+
+func addNumbers(a, b interface{}) interface{} {
+	if isFloat(a) || isFloat(b) {
+		return toFloat(a) + toFloat(b)
+	}
+	x, _ := toInt64(a)
+	y, _ := toInt64(b)
+	if isInt64(a) || isInt64(b) {
+		return x + y
+	}
+	return int(x + y)
+}
+
+func mulNumbers(a, b interface{}) interface{} {
+	if isFloat(a) || isFloat(b) {
+		return toFloat(a) * toFloat(b)
+	}
+	x, _ := toInt64(a)
+	y, _ := toInt64(b)
+	if isInt64(a) || isInt64(b) {
+		return x * y
+	}
+	return int(x * y)
+}
+
+/*-------------------------------- 投影排序 --------------------------------*/
+
+// project 按投影文档返回新文档. 支持包含或排除模式, _id默认包含
+func project(doc bson.M, projection bson.M) (bson.M, error) {
+	if len(projection) == 0 {
+		return doc, nil
+	}
+	include := -1
+	for k, v := range projection {
+		if _, ok := v.(bson.M); ok {
+			return nil, errors.New("unsupported projection operator on field " + k)
+		}
+		if k == "_id" {
+			continue
+		}
+		cur := 0
+		if isTruthy(v) {
+			cur = 1
+		}
+		if include >= 0 && include != cur {
+			return nil, errors.New("projection cannot have a mix of inclusion and exclusion")
+		}
+		include = cur
+	}
+	if include == 0 || include < 0 && !isTruthy(projection["_id"]) {
+		ret := cloneDoc(doc)
+		for k := range projection {
+			unsetPath(ret, k)
+		}
+		return ret, nil
+	}
+	ret := bson.M{}
+	if v, ok := projection["_id"]; !ok || isTruthy(v) {
+		if id, ok := doc["_id"]; ok {
+			ret["_id"] = id
+		}
+	}
+	for k := range projection {
+		if k == "_id" {
+			continue
+		}
+		if v, ok := getPath(doc, k); ok {
+			if err := setPath(ret, k, cloneValue(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// parseSort 解析mgo风格的排序字段, 如"-ctime", "+name"
+func parseSort(fields []string) ([]sortKey, error) {
+	var keys []sortKey
+	for _, f := range fields {
+		for _, f := range strings.Split(f, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			key := sortKey{field: f}
+			switch f[0] {
+			case '-':
+				key.field, key.desc = f[1:], true
+			case '+':
+				key.field = f[1:]
+			case '$':
+				return nil, errors.New("unsupported sort key: " + f)
+			}
+			if key.field == "" {
+				return nil, errors.New("sort: empty field name")
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func sortDocs(docs []bson.M, keys []sortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, k := range keys {
+			a, _ := getPath(docs[i], k.field)
+			b, _ := getPath(docs[j], k.field)
+			if n := compareValues(a, b); n != 0 {
+				if k.desc {
+					return n > 0
+				}
+				return n < 0
+			}
+		}
+		return false
+	})
+}
+
+/*-------------------------------- 聚合管道 --------------------------------*/
+
+// parsePipeline 将pipeline归一化为阶段列表, 使用bson.D以保留$sort的字段顺序
+func parsePipeline(pipeline interface{}) ([]bson.D, error) {
+	if pipeline == nil {
+		return nil, nil
+	}
+	data, err := bson.Marshal(bson.M{"pipeline": pipeline})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Pipeline []bson.D
+	}
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Pipeline, nil
+}
+
+// runPipeline 依次执行聚合阶段. 支持$match, $project, $addFields, $set, $sort, $skip, $limit, $count, $unwind, $group
+func runPipeline(docs []bson.M, stages []bson.D) ([]bson.M, error) {
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, errors.New("a pipeline stage specification object must contain exactly one field")
+		}
+		name, raw := stage[0].Name, stage[0].Value
+		arg := cloneValue(raw)
+		var err error
+		switch name {
+		case "$match":
+			q, ok := arg.(bson.M)
+			if !ok {
+				return nil, errors.New("the match filter must be an expression in an object")
+			}
+			out := docs[:0:0]
+			for _, doc := range docs {
+				ok, err := matchDoc(doc, q)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					out = append(out, doc)
+				}
+			}
+			docs = out
+		case "$project":
+			spec, ok := arg.(bson.M)
+			if !ok {
+				return nil, errors.New("$project specification must be an object")
+			}
+			if docs, err = mapDocs(docs, func(doc bson.M) (bson.M, error) { return projectStage(doc, spec) }); err != nil {
+				return nil, err
+			}
+		case "$addFields", "$set":
+			spec, ok := arg.(bson.M)
+			if !ok {
+				return nil, errors.New(name + " specification stage must be an object")
+			}
+			docs, err = mapDocs(docs, func(doc bson.M) (bson.M, error) {
+				ret := cloneDoc(doc)
+				for k, expr := range spec {
+					v, err := evalExpr(doc, expr)
+					if err != nil {
+						return nil, err
+					}
+					if err = setPath(ret, k, v); err != nil {
+						return nil, err
+					}
+				}
+				return ret, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		case "$sort":
+			spec, ok := raw.(bson.D)
+			if !ok || len(spec) == 0 {
+				return nil, errors.New("the $sort key specification must be a non-empty object")
+			}
+			keys := make([]sortKey, len(spec))
+			for i, e := range spec {
+				n, _ := toInt64(e.Value)
+				keys[i] = sortKey{field: e.Name, desc: n < 0}
+			}
+			docs = append([]bson.M(nil), docs...)
+			sortDocs(docs, keys)
+		case "$skip", "$limit":
+			n, ok := toInt64(arg)
+			if !ok || n < 0 || name == "$limit" && n == 0 {
+				return nil, errors.New("invalid argument to " + name + " stage")
+			}
+			if name == "$skip" {
+				if int(n) >= len(docs) {
+					docs = nil
+				} else {
+					docs = docs[n:]
+				}
+			} else if int(n) < len(docs) {
+				docs = docs[:n]
+			}
+		case "$count":
+			field, ok := arg.(string)
+			if !ok || field == "" {
+				return nil, errors.New("the count field must be a non-empty string")
+			}
+			if len(docs) > 0 {
+				docs = []bson.M{{field: len(docs)}}
+			}
+		case "$unwind":
+			if docs, err = unwindStage(docs, arg); err != nil {
+				return nil, err
+			}
+		case "$group":
+			spec, ok := arg.(bson.M)
+			if !ok {
+				return nil, errors.New("a group's fields must be specified in an object")
+			}
+			if docs, err = groupStage(docs, spec); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.New("unsupported pipeline stage: " + name)
+		}
+	}
+	return docs, nil
+}
+
+func mapDocs(docs []bson.M, f func(doc bson.M) (bson.M, error)) ([]bson.M, error) {
+	out := make([]bson.M, len(docs))
+	for i, doc := range docs {
+		ret, err := f(doc)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = ret
+	}
+	return out, nil
+}
+
+func projectStage(doc bson.M, spec bson.M) (bson.M, error) {
+	flags := bson.M{}
+	exprs := bson.M{}
+	for k, v := range spec {
+		switch v.(type) {
+		case bool, int, int32, int64, float64:
+			flags[k] = v
+		default:
+			exprs[k] = v
+		}
+	}
+	if len(exprs) == 0 {
+		return project(doc, flags)
+	}
+	for k, v := range flags {
+		if k != "_id" && !isTruthy(v) {
+			return nil, errors.New("cannot do exclusion on field " + k + " in inclusion projection")
+		}
+	}
+	if _, ok := flags["_id"]; !ok {
+		flags["_id"] = 1
+	}
+	ret, err := project(doc, flags)
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) == 1 && isTruthy(flags["_id"]) {
+		ret = bson.M{}
+		if id, ok := doc["_id"]; ok {
+			ret["_id"] = id
+		}
+	}
+	for k, expr := range exprs {
+		v, err := evalExpr(doc, expr)
+		if err != nil {
+			return nil, err
+		}
+		if err = setPath(ret, k, v); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func unwindStage(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	path, preserve := "", false
+	switch arg := arg.(type) {
+	case string:
+		path = arg
+	case bson.M:
+		path, _ = arg["path"].(string)
+		preserve = isTruthy(arg["preserveNullAndEmptyArrays"])
+	}
+	if !strings.HasPrefix(path, "$") || len(path) < 2 {
+		return nil, errors.New("$unwind path must be prefixed by a '$'")
+	}
+	path = path[1:]
+	var out []bson.M
+	for _, doc := range docs {
+		v, ok := getPath(doc, path)
+		arr, isArr := v.([]interface{})
+		switch {
+		case isArr && len(arr) > 0:
+			for _, e := range arr {
+				ret := cloneDoc(doc)
+				if err := setPath(ret, path, cloneValue(e)); err != nil {
+					return nil, err
+				}
+				out = append(out, ret)
+			}
+		case ok && v != nil && !isArr:
+			out = append(out, doc)
+		case preserve:
+			ret := cloneDoc(doc)
+			if isArr {
+				unsetPath(ret, path)
+			}
+			out = append(out, ret)
+		}
+	}
+	return out, nil
+}
+
+type groupAcc struct {
+	op    string
+	expr  interface{}
+	val   interface{}
+	count int
+	set   bool
+}
+
+func groupStage(docs []bson.M, spec bson.M) ([]bson.M, error) {
+	idExpr, ok := spec["_id"]
+	if !ok {
+		return nil, errors.New("a group specification must include an _id")
+	}
+	type group struct {
+		id   interface{}
+		accs map[string]*groupAcc
+	}
+	var order []*group
+	groups := make(map[string]*group)
+	for _, doc := range docs {
+		id, err := evalExpr(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+		data, err := bson.Marshal(bson.M{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		g, ok := groups[string(data)]
+		if !ok {
+			g = &group{id: id, accs: make(map[string]*groupAcc)}
+			for field, v := range spec {
+				if field == "_id" {
+					continue
+				}
+				ops, ok := isOperatorDoc(v)
+				if !ok || len(ops) != 1 {
+					return nil, errors.New("the field '" + field + "' must be an accumulator object")
+				}
+				for op, expr := range ops {
+					g.accs[field] = &groupAcc{op: op, expr: expr}
+				}
+			}
+			groups[string(data)] = g
+			order = append(order, g)
+		}
+		for _, acc := range g.accs {
+			if err := acc.add(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	out := make([]bson.M, len(order))
+	for i, g := range order {
+		ret := bson.M{"_id": g.id}
+		for field, acc := range g.accs {
+			ret[field] = acc.result()
+		}
+		out[i] = ret
+	}
+	return out, nil
+}
+
+func (acc *groupAcc) add(doc bson.M) error {
+	v, err := evalExpr(doc, acc.expr)
+	if err != nil {
+		return err
+	}
+	switch acc.op {
+	case "$sum", "$avg":
+		if bsonOrder(v) == 2 {
+			if acc.val == nil {
+				acc.val = 0
+			}
+			acc.val = addNumbers(acc.val, v)
+			acc.count++
+		}
+	case "$min", "$max":
+		if v == nil {
+			return nil
+		}
+		if n := compareValues(v, acc.val); !acc.set || acc.op == "$min" && n < 0 || acc.op == "$max" && n > 0 {
+			acc.val, acc.set = v, true
+		}
+	case "$first":
+		if !acc.set {
+			acc.val, acc.set = v, true
+		}
+	case "$last":
+		acc.val = v
+	case "$push", "$addToSet":
+		arr, _ := acc.val.([]interface{})
+		if arr == nil {
+			arr = []interface{}{}
+		}
+		if v != nil && !(acc.op == "$addToSet" && containsValue(arr, v)) {
+			arr = append(arr, v)
+		}
+		acc.val = arr
+	default:
+		return errors.New("unknown group operator '" + acc.op + "'")
+	}
+	return nil
+}
+
+func (acc *groupAcc) result() interface{} {
+	switch acc.op {
+	case "$sum":
+		if acc.val == nil {
+			return 0
+		}
+	case "$avg":
+		if acc.count == 0 {
+			return nil
+		}
+		return toFloat(acc.val) / float64(acc.count)
+	}
+	return acc.val
+}
+
+// evalExpr 计算聚合表达式. 支持字段路径"$a.b", "$$ROOT", 对象, 数组及$literal, $add, $subtract, $multiply, $divide, $concat, $size, $ifNull
+func evalExpr(doc bson.M, expr interface{}) (interface{}, error) {
+	switch expr := expr.(type) {
+	case string:
+		if expr == "$$ROOT" {
+			return doc, nil
+		}
+		if strings.HasPrefix(expr, "$") {
+			v, _ := getPath(doc, expr[1:])
+			return v, nil
+		}
+		return expr, nil
+	case []interface{}:
+		ret := make([]interface{}, len(expr))
+		for i, e := range expr {
+			v, err := evalExpr(doc, e)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = v
+		}
+		return ret, nil
+	case bson.M:
+		if ops, ok := isOperatorDoc(expr); ok {
+			if len(ops) != 1 {
+				return nil, errors.New("an expression specification must contain exactly one field")
+			}
+			for op, arg := range ops {
+				return evalOp(doc, op, arg)
+			}
+		}
+		ret := bson.M{}
+		for k, e := range expr {
+			v, err := evalExpr(doc, e)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = v
+		}
+		return ret, nil
+	}
+	return expr, nil
+}
+
+func evalOp(doc bson.M, op string, arg interface{}) (interface{}, error) {
+	if op == "$literal" {
+		return arg, nil
+	}
+	v, err := evalExpr(doc, arg)
+	if err != nil {
+		return nil, err
+	}
+	args, isArr := v.([]interface{})
+	if !isArr {
+		args = []interface{}{v}
+	}
+	for _, a := range args {
+		if a == nil && op != "$ifNull" {
+			return nil, nil
+		}
+	}
+	switch op {
+	case "$add", "$multiply":
+		var ret interface{} = 0
+		if op == "$multiply" {
+			ret = 1
+		}
+		for _, a := range args {
+			if bsonOrder(a) != 2 {
+				return nil, fmt.Errorf("%s only supports numeric types, not %T", op, a)
+			}
+			if op == "$add" {
+				ret = addNumbers(ret, a)
+			} else {
+				ret = mulNumbers(ret, a)
+			}
+		}
+		return ret, nil
+	case "$subtract", "$divide":
+		if len(args) != 2 || bsonOrder(args[0]) != 2 || bsonOrder(args[1]) != 2 {
+			return nil, errors.New(op + " needs two numeric arguments")
+		}
+		if op == "$subtract" {
+			return addNumbers(args[0], mulNumbers(args[1], -1)), nil
+		}
+		if toFloat(args[1]) == 0 {
+			return nil, errors.New("can't $divide by zero")
+		}
+		return toFloat(args[0]) / toFloat(args[1]), nil
+	case "$concat":
+		ret := ""
+		for _, a := range args {
+			s, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("$concat only supports strings, not %T", a)
+			}
+			ret += s
+		}
+		return ret, nil
+	case "$size":
+		if len(args) == 1 {
+			if arr, ok := args[0].([]interface{}); ok {
+				return len(arr), nil
+			}
+		}
+		return nil, errors.New("the argument to $size must be an array")
+	case "$ifNull":
+		if len(args) != 2 {
+			return nil, errors.New("$ifNull needs two arguments")
+		}
+		if args[0] != nil {
+			return args[0], nil
+		}
+		return args[1], nil
+	}
+	return nil, errors.New("unsupported expression operator: " + op)
+}
@@ -0,0 +1,428 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// 未配置conf.yml时使用内存实现作为Default
+func TestMain(m *testing.M) {
+	if Default == nil {
+		if err := Setup("memory", &Config{Database: "jx3robot", Memory: true}, true); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+type memUser struct {
+	Id    int      `bson:"_id"`
+	Name  string   `bson:"name"`
+	Age   int      `bson:"age"`
+	Tags  []string `bson:"tags,omitempty"`
+	Ctime int64    `bson:"ctime"`
+}
+
+func newMemoryFixture(t *testing.T) Mongo {
+	m := newMemoryMongo(&Config{Database: "test"})
+	err := m.Insert("user",
+		memUser{Id: 1, Name: "alice", Age: 30, Tags: []string{"a", "b"}, Ctime: 100},
+		memUser{Id: 2, Name: "bob", Age: 25, Tags: []string{"b"}, Ctime: 300},
+		memUser{Id: 3, Name: "carol", Age: 35, Ctime: 200},
+		bson.M{"_id": 4, "name": "dave", "age": 25, "ctime": int64(400), "extra": true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func ids(us []memUser) []int {
+	ret := make([]int, len(us))
+	for i, u := range us {
+		ret[i] = u.Id
+	}
+	return ret
+}
+
+func TestMemoryQuery(t *testing.T) {
+	m := newMemoryFixture(t)
+	cases := []struct {
+		query interface{}
+		want  []int
+	}{
+		{nil, []int{1, 2, 3, 4}},
+		{bson.M{"age": 25}, []int{2, 4}},
+		{bson.M{"age": bson.M{"$eq": 30}}, []int{1}},
+		{bson.M{"age": bson.M{"$gt": 25, "$lt": 35}}, []int{1}},
+		{bson.M{"age": bson.M{"$gte": 30}}, []int{1, 3}},
+		{bson.M{"name": bson.M{"$in": []string{"bob", "carol"}}}, []int{2, 3}},
+		{bson.M{"name": bson.M{"$regex": "^A", "$options": "i"}}, []int{1}},
+		{bson.M{"name": bson.RegEx{Pattern: "o"}}, []int{2, 3}},
+		{bson.M{"extra": bson.M{"$exists": true}}, []int{4}},
+		{bson.M{"tags": "b"}, []int{1, 2}},
+		{bson.M{"$or": []bson.M{{"age": 35}, {"name": "bob"}}}, []int{2, 3}},
+		{bson.M{"$and": []bson.M{{"age": 25}, {"ctime": bson.M{"$gt": 300}}}}, []int{4}},
+	}
+	for _, cs := range cases {
+		var us []memUser
+		if err := m.FindAll("user", &us, cs.query, "_id"); err != nil {
+			t.Fatalf("%v: %v", cs.query, err)
+		}
+		if got := ids(us); !reflect.DeepEqual(got, cs.want) {
+			t.Errorf("%v: got %v, want %v", cs.query, got, cs.want)
+		}
+	}
+}
+
+func TestMemoryPageAndProjection(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	var tot uint32
+	var us []memUser
+	if err := m.FindPage("user", &tot, &us, nil, 1, 2, "-ctime"); err != nil {
+		t.Fatal(err)
+	}
+	if tot != 4 || !reflect.DeepEqual(ids(us), []int{2, 3}) {
+		t.Errorf("FindPage: tot=%d ids=%v", tot, ids(us))
+	}
+
+	var ms []bson.M
+	if err := m.SelectAll("user", &ms, bson.M{"_id": 1}, bson.M{"name": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ms, []bson.M{{"_id": 1, "name": "alice"}}) {
+		t.Errorf("SelectAll: %v", ms)
+	}
+
+	var ages []int
+	if err := m.FindDistinct("user", &ages, nil, "age"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ages, []int{30, 25, 35}) {
+		t.Errorf("FindDistinct: %v", ages)
+	}
+}
+
+func TestMemoryCount(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	cases := []struct {
+		query interface{}
+		opt   *CountOptions
+		n     int
+	}{
+		{bson.M{"age": 25}, nil, 2},
+		{bson.M{"age": bson.M{"$gte": 25}}, &CountOptions{Skip: 1}, 3},
+		{bson.M{"age": bson.M{"$gte": 25}}, &CountOptions{Skip: 1, Limit: 2}, 2},
+		{bson.M{"age": 25}, &CountOptions{Skip: 5}, 0},
+		{nil, &CountOptions{Limit: 3, Hint: []string{"age"}}, 3},
+	}
+	for _, cs := range cases {
+		if n, err := m.CountWhere("user", cs.query, cs.opt); n != cs.n || err != nil {
+			t.Errorf("CountWhere %v %+v: %v %v", cs.query, cs.opt, n, err)
+		}
+	}
+	if n, err := m.EstimatedCount("user"); n != 4 || err != nil {
+		t.Errorf("EstimatedCount: %v %v", n, err)
+	}
+	if n, err := m.DBCountWhere("test", "none", bson.M{"age": 25}); n != 0 || err != nil {
+		t.Errorf("DBCountWhere missing collection: %v %v", n, err)
+	}
+	if ok, err := m.Exists("user", bson.M{"name": "carol"}); !ok || err != nil {
+		t.Errorf("Exists: %v %v", ok, err)
+	}
+	if ok, err := m.Exists("user", bson.M{"age": bson.M{"$gt": 40}}); ok || err != nil {
+		t.Errorf("Exists no match: %v %v", ok, err)
+	}
+	if ok, err := m.DBExists("test", "none", nil); ok || err != nil {
+		t.Errorf("DBExists missing collection: %v %v", ok, err)
+	}
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	ok, err := m.UpdateId("user", 1, bson.M{
+		"$set":  bson.M{"name": "alice2"},
+		"$inc":  bson.M{"age": 1},
+		"$push": bson.M{"tags": "c"},
+		"$pull": bson.M{"tags": "a"},
+	})
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	var u memUser
+	if _, err = m.FindId("user", &u, 1); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "alice2" || u.Age != 31 || !reflect.DeepEqual(u.Tags, []string{"b", "c"}) {
+		t.Errorf("UpdateId: %+v", u)
+	}
+
+	if ok, err = m.UpdateOne("user", bson.M{"_id": 99}, bson.M{"$unset": bson.M{"age": ""}}); ok || err != nil {
+		t.Errorf("UpdateOne not found: %v %v", ok, err)
+	}
+	if n, err := m.UpdateAll("user", bson.M{"age": 25}, bson.M{"$set": bson.M{"age": 25}}); n != 0 || err != nil {
+		t.Errorf("UpdateAll unchanged: %v %v", n, err)
+	}
+	if n, err := m.UpdateAll("user", bson.M{"age": 25}, bson.M{"$unset": bson.M{"tags": ""}}); n != 1 || err != nil {
+		t.Errorf("UpdateAll: %v %v", n, err)
+	}
+
+	ret, err := m.UpsertOne("user", bson.M{"name": "eve"}, bson.M{"$set": bson.M{"age": 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci := ret.(*mgo.ChangeInfo); ci.UpsertedId == nil {
+		t.Errorf("UpsertOne: %+v", ci)
+	}
+	if ok, _ = m.FindOne("user", &u, bson.M{"name": "eve", "age": 20}); !ok {
+		t.Error("UpsertOne: document not inserted")
+	}
+
+	if err = m.Insert("user", bson.M{"_id": 1}); !mgo.IsDup(err) {
+		t.Errorf("Insert duplicate: %v", err)
+	}
+}
+
+func TestMemoryFindAndModify(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	var u memUser
+	n, err := m.FindAndUpdate("user", &u, bson.M{"_id": 2}, bson.M{"$set": bson.M{"age": 26}})
+	if n != 1 || err != nil || u.Age != 25 {
+		t.Errorf("FindAndUpdate: %v %v %+v", n, err, u)
+	}
+	n, err = m.FindAndUpdateRN("user", &u, bson.M{"_id": 2}, bson.M{"$inc": bson.M{"age": 1}})
+	if n != 1 || err != nil || u.Age != 27 {
+		t.Errorf("FindAndUpdateRN: %v %v %+v", n, err, u)
+	}
+	if _, err = m.FindAndUpdate("user", &u, bson.M{"_id": 99}, bson.M{"$set": bson.M{"age": 1}}); err != mgo.ErrNotFound {
+		t.Errorf("FindAndUpdate not found: %v", err)
+	}
+	if n, err = m.FindAndUpdateRN("user", &u, bson.M{"_id": 99}, bson.M{"$set": bson.M{"age": 1}}); n != 0 || err != nil {
+		t.Errorf("FindAndUpdateRN not found: %v %v", n, err)
+	}
+	id, err := m.FindAndUpsertRN("user", &u, bson.M{"_id": 9}, bson.M{"$set": bson.M{"name": "zed"}})
+	if id != 9 || err != nil || u.Name != "zed" {
+		t.Errorf("FindAndUpsertRN: %v %v %+v", id, err, u)
+	}
+	if n, err = m.FindAndRemove("user", &u, bson.M{"_id": 3}); n != 1 || err != nil || u.Name != "carol" {
+		t.Errorf("FindAndRemove: %v %v %+v", n, err, u)
+	}
+	if n, err = m.FindAndRemove("user", &u, bson.M{"_id": 3}); n != 0 || err != nil {
+		t.Errorf("FindAndRemove not found: %v %v", n, err)
+	}
+}
+
+func TestMemoryRunBulk(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	matched, modified, err := m.RunBulk("user", func(bk Bulk, args ...interface{}) {
+		bk.Insert(bson.M{"_id": 43, "name": "hehe"})
+		bk.UpdateOne(bson.M{"_id": 43}, bson.M{"$set": bson.M{"name": "haha"}})
+		bk.UpdateAll(bson.M{"age": 25}, bson.M{"$inc": bson.M{"age": 1}})
+		bk.RemoveOne(bson.M{"_id": 1})
+	})
+	if matched != 4 || modified != 3 || err != nil {
+		t.Errorf("RunBulk: %v %v %v", matched, modified, err)
+	}
+	if n, _ := m.Count("user"); n != 4 {
+		t.Errorf("Count: %v", n)
+	}
+	if ok, err := m.RemoveId("user", 1); ok || err != nil {
+		t.Errorf("RemoveId removed: %v %v", ok, err)
+	}
+	if _, err := m.RunCollection("user", nil); err != ErrNotSupported {
+		t.Errorf("RunCollection: %v", err)
+	}
+}
+
+func TestMemoryContext(t *testing.T) {
+	m := newMemoryFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var us []memUser
+	if err := m.FindAllCtx(ctx, "user", &us, nil); err != context.Canceled {
+		t.Errorf("FindAllCtx: %v", err)
+	}
+}
+
+func TestMemoryAggregate(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	var rs []struct {
+		Age   int      `bson:"_id"`
+		Count int      `bson:"count"`
+		Names []string `bson:"names"`
+	}
+	err := m.Aggregate("user", &rs, []bson.M{
+		{"$match": bson.M{"age": bson.M{"$lt": 35}}},
+		{"$group": bson.M{"_id": "$age", "count": bson.M{"$sum": 1}, "names": bson.M{"$push": "$name"}}},
+		{"$sort": bson.D{{Name: "count", Value: -1}, {Name: "_id", Value: 1}}},
+	}, &PipeOptions{AllowDiskUse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].Age != 25 || rs[0].Count != 2 || !reflect.DeepEqual(rs[0].Names, []string{"bob", "dave"}) || rs[1].Age != 30 {
+		t.Errorf("Aggregate: %+v", rs)
+	}
+
+	var tags struct {
+		N int `bson:"n"`
+	}
+	ok, err := m.AggregateOne("user", &tags, []bson.M{{"$unwind": "$tags"}, {"$count": "n"}})
+	if !ok || err != nil || tags.N != 3 {
+		t.Errorf("AggregateOne: %v %v %+v", ok, err, tags)
+	}
+	if ok, err = m.AggregateOne("user", &tags, []bson.M{{"$match": bson.M{"_id": 99}}}); ok || err != nil {
+		t.Errorf("AggregateOne empty: %v %v", ok, err)
+	}
+}
+
+func TestMemoryIter(t *testing.T) {
+	m := newMemoryFixture(t)
+
+	it := m.SelectIter("user", bson.M{"age": bson.M{"$lte": 30}}, bson.M{"name": 1}, 2, "-ctime")
+	var names []string
+	var u memUser
+	for it.Next(&u) {
+		names = append(names, u.Name)
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"dave", "bob", "alice"}) || u.Age != 0 {
+		t.Errorf("SelectIter: %v %+v", names, u)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it = m.FindIterCtx(ctx, "user", nil, 0, "_id")
+	if !it.Next(&u) || u.Id != 1 {
+		t.Errorf("FindIterCtx: %+v", u)
+	}
+	cancel()
+	if it.Next(&u) || it.Close() != context.Canceled {
+		t.Errorf("FindIterCtx canceled: %v", it.Err())
+	}
+	if it = m.FindIter("user", bson.M{"$bad": 1}, 0); it.Next(&u) || it.Close() == nil {
+		t.Error("FindIter bad query: expected error")
+	}
+}
+
+func TestMemoryKeyset(t *testing.T) {
+	m := newMemoryFixture(t)
+	m.Insert("user", memUser{Id: 5, Name: "erin", Age: 25, Ctime: 300})
+
+	// ctime: 1=100 3=200 2=300 5=300 4=400
+	var us []memUser
+	pages := [][]int{{4, 2}, {5, 3}, {1}}
+	token := ""
+	var tokens []string
+	for i, want := range pages {
+		next, prev, err := m.FindKeyset("user", &us, nil, token, 2, "-ctime,_id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids(us), want) || (prev == "") != (i == 0) || (next == "") != (i == len(pages)-1) {
+			t.Fatalf("page %d: ids=%v next=%q prev=%q", i, ids(us), next, prev)
+		}
+		tokens = append(tokens, prev)
+		token = next
+	}
+
+	// 从最后一页向前翻
+	next, prev, err := m.FindKeyset("user", &us, nil, tokens[2], 2, "-ctime", "_id")
+	if err != nil || !reflect.DeepEqual(ids(us), []int{5, 3}) || next == "" || prev == "" {
+		t.Fatalf("prev page: ids=%v next=%q prev=%q err=%v", ids(us), next, prev, err)
+	}
+	if _, prev, err = m.FindKeyset("user", &us, nil, prev, 2, "-ctime,_id"); err != nil || !reflect.DeepEqual(ids(us), []int{4, 2}) || prev != "" {
+		t.Fatalf("first page: ids=%v prev=%q err=%v", ids(us), prev, err)
+	}
+
+	var ms []bson.M
+	if _, _, err = m.SelectKeyset("user", &ms, bson.M{"age": 25}, bson.M{"name": 1}, "", 0, "name"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ms, []bson.M{{"_id": 2, "name": "bob"}, {"_id": 4, "name": "dave"}, {"_id": 5, "name": "erin"}}) {
+		t.Errorf("SelectKeyset: %v", ms)
+	}
+	if _, _, err = m.FindKeyset("user", &us, nil, tokens[1], 2, "ctime"); err != ErrInvalidToken {
+		t.Errorf("FindKeyset token/sort mismatch: %v", err)
+	}
+
+	// 投影未包含或排除了排序键时, 查询时补上, 结果仍与投影一致
+	next, _, err = m.SelectKeyset("user", &ms, nil, bson.M{"name": 1}, "", 2, "-ctime")
+	if err != nil || !reflect.DeepEqual(ms, []bson.M{{"_id": 4, "name": "dave"}, {"_id": 2, "name": "bob"}}) {
+		t.Fatalf("SelectKeyset inclusion without sort key: %v %v", ms, err)
+	}
+	if _, _, err = m.SelectKeyset("user", &ms, nil, bson.M{"name": 1}, next, 2, "-ctime"); err != nil ||
+		!reflect.DeepEqual(ms, []bson.M{{"_id": 5, "name": "erin"}, {"_id": 3, "name": "carol"}}) {
+		t.Errorf("SelectKeyset inclusion next page: %v %v", ms, err)
+	}
+	if _, _, err = m.SelectKeyset("user", &ms, nil, bson.M{"_id": 0, "name": 1}, "", 1, "-ctime"); err != nil || !reflect.DeepEqual(ms, []bson.M{{"name": "dave"}}) {
+		t.Errorf("SelectKeyset inclusion without _id: %v %v", ms, err)
+	}
+	next, _, err = m.SelectKeyset("user", &ms, bson.M{"age": 25}, bson.M{"_id": 0, "ctime": 0, "tags": 0, "extra": 0}, "", 2, "ctime")
+	if err != nil || !reflect.DeepEqual(ms, []bson.M{{"name": "bob", "age": 25}, {"name": "erin", "age": 25}}) {
+		t.Fatalf("SelectKeyset exclusion of sort key: %v %v", ms, err)
+	}
+	if _, _, err = m.SelectKeyset("user", &ms, bson.M{"age": 25}, bson.M{"_id": 0, "ctime": 0, "tags": 0, "extra": 0}, next, 2, "ctime"); err != nil ||
+		!reflect.DeepEqual(ms, []bson.M{{"name": "dave", "age": 25}}) {
+		t.Errorf("SelectKeyset exclusion next page: %v %v", ms, err)
+	}
+}
+
+func TestMemoryStrictUpdate(t *testing.T) {
+	m := newMemoryFixture(t)
+	m.(*memMongo).Config.Strict = true
+
+	if _, err := m.UpdateId("user", 1, bson.M{"name": "x"}); err != ErrUpdateWithoutOperator {
+		t.Errorf("UpdateId replacement: %v", err)
+	}
+	if _, err := m.FindAndUpdate("user", nil, bson.M{"_id": 1}, bson.M{"$set": bson.M{"a": 1}, "name": "x"}); err != ErrUpdateWithoutOperator {
+		t.Errorf("FindAndUpdate mixed: %v", err)
+	}
+	if _, err := m.UpsertOne("user", bson.M{"_id": 9}, nil); err != ErrUpdateWithoutOperator {
+		t.Errorf("UpsertOne nil: %v", err)
+	}
+	if _, err := m.UpdateId("user", 1, U()); err != ErrUpdateWithoutOperator {
+		t.Errorf("UpdateId empty builder: %v", err)
+	}
+	var nilUpdate *Update
+	if _, err := m.UpdateId("user", 1, nilUpdate); err != ErrUpdateWithoutOperator {
+		t.Errorf("UpdateId nil builder: %v", err)
+	}
+
+	ok, err := m.UpdateId("user", 1, U().Set("name", "alice2").Inc("age", 1).Push("tags", "c").Pull("tags", "a").Unset("ctime"))
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	var u memUser
+	m.FindId("user", &u, 1)
+	if u.Name != "alice2" || u.Age != 31 || u.Ctime != 0 || !reflect.DeepEqual(u.Tags, []string{"b", "c"}) {
+		t.Errorf("UpdateId builder: %+v", u)
+	}
+
+	if _, err = m.UpsertId("user", 9, U().SetOnInsert("name", "zed").Max("age", 20).Min("ctime", int64(5)).AddToSet("tags", "z")); err != nil {
+		t.Fatal(err)
+	}
+	m.FindId("user", &u, 9)
+	if u.Name != "zed" || u.Age != 20 || u.Ctime != 5 || !reflect.DeepEqual(u.Tags, []string{"z"}) {
+		t.Errorf("UpsertId builder: %+v", u)
+	}
+
+	if ok, err = m.UpdateId("user", 2, Replace(bson.M{"name": "bob2"})); !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	var doc bson.M
+	m.FindId("user", &doc, 2)
+	if !reflect.DeepEqual(doc, bson.M{"_id": 2, "name": "bob2"}) {
+		t.Errorf("UpdateId Replace: %v", doc)
+	}
+}
@@ -0,0 +1,198 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/globalsign/mgo"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 延迟直方图的桶上限(秒)
+var MetricsBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type opMetricKey struct {
+	client     string
+	database   string
+	collection string
+	operation  string
+}
+
+type opMetric struct {
+	count   uint64
+	errors  uint64
+	sum     float64
+	buckets []uint64 // 累计计数, 与MetricsBuckets一一对应
+}
+
+type clientMetric struct {
+	inflight    int64
+	maxPoolSize int
+}
+
+// metricsRegistry 所有开启metrics的实例共用, 由MetricsHandler导出
+type metricsRegistry struct {
+	sync.Mutex
+	clients map[string]*clientMetric
+	ops     map[opMetricKey]*opMetric
+}
+
+var defaultMetrics = &metricsRegistry{
+	clients: make(map[string]*clientMetric),
+	ops:     make(map[opMetricKey]*opMetric),
+}
+
+// register 返回统计client操作的拦截器, mgo的socket统计随之开启
+func (r *metricsRegistry) register(client string, opt *Config) Interceptor {
+	mgo.SetStats(true)
+
+	cm := &clientMetric{maxPoolSize: opt.MaxPoolSize}
+	r.Lock()
+	r.clients[client] = cm
+	r.Unlock()
+
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		atomic.AddInt64(&cm.inflight, 1)
+		start := time.Now()
+		err := next(ctx, op)
+		r.observe(opMetricKey{client, op.Database, op.Collection, op.Name}, time.Since(start), err)
+		atomic.AddInt64(&cm.inflight, -1)
+		return err
+	}
+}
+
+func (r *metricsRegistry) observe(key opMetricKey, d time.Duration, err error) {
+	sec := d.Seconds()
+	r.Lock()
+	defer r.Unlock()
+
+	om, ok := r.ops[key]
+	if !ok {
+		om = &opMetric{buckets: make([]uint64, len(MetricsBuckets))}
+		r.ops[key] = om
+	}
+	om.count++
+	if err != nil {
+		om.errors++
+	}
+	om.sum += sec
+	for i, le := range MetricsBuckets {
+		if sec <= le {
+			om.buckets[i]++
+		}
+	}
+}
+
+// write 以Prometheus文本格式输出
+func (r *metricsRegistry) write(buf *bytes.Buffer) {
+	r.Lock()
+	keys := make([]opMetricKey, 0, len(r.ops))
+	ops := make(map[opMetricKey]opMetric, len(r.ops))
+	for k, v := range r.ops {
+		keys = append(keys, k)
+		ops[k] = opMetric{count: v.count, errors: v.errors, sum: v.sum, buckets: append([]uint64(nil), v.buckets...)}
+	}
+	clients := make([]string, 0, len(r.clients))
+	cms := make(map[string]*clientMetric, len(r.clients))
+	for k, v := range r.clients {
+		clients = append(clients, k)
+		cms[k] = v
+	}
+	r.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.client != b.client {
+			return a.client < b.client
+		}
+		if a.database != b.database {
+			return a.database < b.database
+		}
+		if a.collection != b.collection {
+			return a.collection < b.collection
+		}
+		return a.operation < b.operation
+	})
+	sort.Strings(clients)
+
+	buf.WriteString("# HELP mongo_operations_total Total number of mongo operations.\n# TYPE mongo_operations_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(buf, "mongo_operations_total{%s} %d\n", k.labels(), ops[k].count)
+	}
+	buf.WriteString("# HELP mongo_operation_errors_total Total number of mongo operations returning an error.\n# TYPE mongo_operation_errors_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(buf, "mongo_operation_errors_total{%s} %d\n", k.labels(), ops[k].errors)
+	}
+	buf.WriteString("# HELP mongo_operation_duration_seconds Latency of mongo operations.\n# TYPE mongo_operation_duration_seconds histogram\n")
+	for _, k := range keys {
+		om, lbs := ops[k], k.labels()
+		for i, le := range MetricsBuckets {
+			fmt.Fprintf(buf, "mongo_operation_duration_seconds_bucket{%s,le=\"%s\"} %d\n", lbs, formatFloat(le), om.buckets[i])
+		}
+		fmt.Fprintf(buf, "mongo_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", lbs, om.count)
+		fmt.Fprintf(buf, "mongo_operation_duration_seconds_sum{%s} %s\n", lbs, formatFloat(om.sum))
+		fmt.Fprintf(buf, "mongo_operation_duration_seconds_count{%s} %d\n", lbs, om.count)
+	}
+
+	buf.WriteString("# HELP mongo_inflight_operations Number of operations in progress, each holding a copied session.\n# TYPE mongo_inflight_operations gauge\n")
+	for _, c := range clients {
+		fmt.Fprintf(buf, "mongo_inflight_operations{client=%s} %d\n", quoteLabel(c), atomic.LoadInt64(&cms[c].inflight))
+	}
+	// 每个进行中的操作持有一个拷贝的会话, mgo不提供按实例的会话数
+	buf.WriteString("# HELP mongo_sessions_in_use Number of copied sessions in use, equal to mongo_inflight_operations.\n# TYPE mongo_sessions_in_use gauge\n")
+	for _, c := range clients {
+		fmt.Fprintf(buf, "mongo_sessions_in_use{client=%s} %d\n", quoteLabel(c), atomic.LoadInt64(&cms[c].inflight))
+	}
+	buf.WriteString("# HELP mongo_pool_max_size Configured maximum pool size per server, 0 means the mgo default.\n# TYPE mongo_pool_max_size gauge\n")
+	for _, c := range clients {
+		fmt.Fprintf(buf, "mongo_pool_max_size{client=%s} %d\n", quoteLabel(c), cms[c].maxPoolSize)
+	}
+
+	// mgo的统计是进程级的, 不区分实例, 因此不带client标签
+	st := mgo.GetStats()
+	gauges := []struct {
+		name string
+		help string
+		val  int
+	}{
+		{"mongo_clusters", "Number of mgo clusters. Process-wide, not per client.", st.Clusters},
+		{"mongo_master_connections", "Number of connections to master servers. Process-wide, not per client.", st.MasterConns},
+		{"mongo_slave_connections", "Number of connections to slave servers. Process-wide, not per client.", st.SlaveConns},
+		{"mongo_sockets_alive", "Number of alive sockets. Process-wide, not per client.", st.SocketsAlive},
+		{"mongo_sockets_in_use", "Number of sockets in use. Process-wide, not per client.", st.SocketsInUse},
+		{"mongo_socket_refs", "Number of session references to sockets. Process-wide, not per client.", st.SocketRefs},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, g.val)
+	}
+}
+
+func (k opMetricKey) labels() string {
+	return "client=" + quoteLabel(k.client) + ",database=" + quoteLabel(k.database) + ",collection=" + quoteLabel(k.collection) + ",operation=" + quoteLabel(k.operation)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelReplacer.Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// MetricsHandler 以Prometheus文本格式导出开启metrics的实例的指标, client标签为Setup的name
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
+		defaultMetrics.write(buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo/bson"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	if err := Setup("metrics_a, metrics_b", &Config{Database: "test", Memory: true, Metrics: true, MaxPoolSize: 16}, false); err != nil {
+		t.Fatal(err)
+	}
+	m := Get("metrics_a")
+	m.Insert("user", bson.M{"_id": 1})
+	m.Insert("user", bson.M{"_id": 1})
+	var us []bson.M
+	m.FindAll("user", &us, nil)
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, line := range []string{
+		`mongo_operations_total{client="metrics_a,metrics_b",database="test",collection="user",operation="Insert"} 2`,
+		`mongo_operation_errors_total{client="metrics_a,metrics_b",database="test",collection="user",operation="Insert"} 1`,
+		`mongo_operation_duration_seconds_bucket{client="metrics_a,metrics_b",database="test",collection="user",operation="FindAll",le="+Inf"} 1`,
+		`mongo_operation_duration_seconds_count{client="metrics_a,metrics_b",database="test",collection="user",operation="FindAll"} 1`,
+		`mongo_inflight_operations{client="metrics_a,metrics_b"} 0`,
+		`mongo_sessions_in_use{client="metrics_a,metrics_b"} 0`,
+		`mongo_pool_max_size{client="metrics_a,metrics_b"} 16`,
+		"# TYPE mongo_sockets_in_use gauge",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
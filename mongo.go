@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"context"
 	"errors"
 	"github.com/globalsign/mgo"
 	"strings"
@@ -20,13 +21,74 @@ type BulkFunc func(bk Bulk, args ...interface{})
 type SessionFunc func(se *mgo.Session, args ...interface{}) (interface{}, error)
 type CollectionFunc func(cl *mgo.Collection, args ...interface{}) (interface{}, error)
 
+// PipeOptions 聚合选项, 多个时仅第一个有效
+type PipeOptions struct {
+	AllowDiskUse bool          // 允许使用磁盘临时文件
+	BatchSize    int           // 游标批量大小, 默认由服务端决定
+	MaxTime      time.Duration // 服务端maxTimeMS, 与ctx的deadline同时存在时取较小值
+}
+
+func pipeOptions(opts []*PipeOptions) *PipeOptions {
+	if len(opts) > 0 && opts[0] != nil {
+		return opts[0]
+	}
+	return new(PipeOptions)
+}
+
+// CountOptions 条件计数选项, 多个时仅第一个有效
+type CountOptions struct {
+	Skip  uint32
+	Limit uint32   // 0表示不限制
+	Hint  []string // 强制使用索引, 格式与EnsureIndexKey相同, 优先于WithHint
+}
+
+func countOptions(opts []*CountOptions) *CountOptions {
+	if len(opts) > 0 && opts[0] != nil {
+		return opts[0]
+	}
+	return new(CountOptions)
+}
+
+// Iter 流式读取结果集, 持有拷贝的会话, 使用完毕必须Close释放
+type Iter interface {
+	Next(ret interface{}) bool // 读取下一条到ret, 结束或出错时返回false
+	Err() error
+	Close() error // 释放会话, 返回迭代过程中的错误
+}
+
+// errIter 创建时即已失败的迭代器
+type errIter struct {
+	err error
+}
+
+func (it *errIter) Next(ret interface{}) bool {
+	return false
+}
+func (it *errIter) Err() error {
+	return it.err
+}
+func (it *errIter) Close() error {
+	return it.err
+}
+
 type Mongo interface {
 	Count(c string) (n int, err error)
+	CountWhere(c string, query interface{}, opts ...*CountOptions) (n int, err error)
+	EstimatedCount(c string) (n int, err error)
+	Exists(c string, query interface{}) (bool, error)
 	Indexes(c string) (indexes []mgo.Index, err error)
 	EnsureIndex(c string, index mgo.Index) error
 	EnsureIndexKey(c string, key ...string) error
 	DropIndex(c string, key ...string) error
 	DropIndexName(c string, name string) error
+	CreateCollection(c string, info *mgo.CollectionInfo) error
+	DropCollection(c string) error
+	RenameCollection(c string, to string, dropTarget bool) error
+	CollectionStats(c string) (*CollStats, error)
+	ListCollections() ([]CollectionSpec, error)
+	DropDatabase() error
+	DatabaseStats() (*DBStats, error)
+	ListDatabases() ([]DatabaseSpec, error)
 
 	// For whole document
 	FindOne(c string, ret interface{}, query interface{}) (bool, error)
@@ -42,6 +104,15 @@ type Mongo interface {
 	SelectPage(c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
 	SelectDistinct(c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error
 	SelectId(c string, ret interface{}, id interface{}, projection interface{}) (bool, error)
+	// Aggregate
+	Aggregate(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error
+	AggregateOne(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error)
+	// Iterator, 使用完毕必须Close
+	FindIter(c string, query interface{}, batch int, sort ...string) Iter
+	SelectIter(c string, query interface{}, projection interface{}, batch int, sort ...string) Iter
+	// Keyset分页, 不使用skip与count
+	FindKeyset(c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	SelectKeyset(c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
 	// FindAndModify
 	FindAndUpdate(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)              // return old doucument
 	FindAndUpsert(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error)   // return old doucument
@@ -62,11 +133,21 @@ type Mongo interface {
 	RunCollection(c string, f CollectionFunc, args ...interface{}) (interface{}, error)
 
 	DBCount(d string, c string) (n int, err error)
+	DBCountWhere(d string, c string, query interface{}, opts ...*CountOptions) (n int, err error)
+	DBEstimatedCount(d string, c string) (n int, err error)
+	DBExists(d string, c string, query interface{}) (bool, error)
 	DBIndexes(d string, c string) (indexes []mgo.Index, err error)
 	DBEnsureIndex(d string, c string, index mgo.Index) error
 	DBEnsureIndexKey(d string, c string, key ...string) error
 	DBDropIndex(d string, c string, key ...string) error
 	DBDropIndexName(d string, c string, name string) error
+	DBCreateCollection(d string, c string, info *mgo.CollectionInfo) error
+	DBDropCollection(d string, c string) error
+	DBRenameCollection(d string, c string, to string, dropTarget bool) error
+	DBCollectionStats(d string, c string) (*CollStats, error)
+	DBListCollections(d string) ([]CollectionSpec, error)
+	DBDropDatabase(d string) error
+	DBDatabaseStats(d string) (*DBStats, error)
 
 	// For whole document
 	DBFindOne(d string, c string, ret interface{}, query interface{}) (bool, error)
@@ -82,6 +163,15 @@ type Mongo interface {
 	DBSelectPage(d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
 	DBSelectDistinct(d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error
 	DBSelectId(d string, c string, ret interface{}, id interface{}, projection interface{}) (bool, error)
+	// Aggregate
+	DBAggregate(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error
+	DBAggregateOne(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error)
+	// Iterator, 使用完毕必须Close
+	DBFindIter(d string, c string, query interface{}, batch int, sort ...string) Iter
+	DBSelectIter(d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter
+	// Keyset分页, 不使用skip与count
+	DBFindKeyset(d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	DBSelectKeyset(d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
 	// FindAndModify
 	DBFindAndUpdate(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)              // return old doucument
 	DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error)   // return old doucument
@@ -102,240 +192,793 @@ type Mongo interface {
 	DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error)
 
 	RunSession(f SessionFunc, args ...interface{}) (interface{}, error)
+
+	// With context.Context: deadline转为服务端maxTimeMS, 取消时关闭拷贝会话并返回ctx.Err()
+	CountCtx(ctx context.Context, c string) (n int, err error)
+	CountWhereCtx(ctx context.Context, c string, query interface{}, opts ...*CountOptions) (n int, err error)
+	EstimatedCountCtx(ctx context.Context, c string) (n int, err error)
+	ExistsCtx(ctx context.Context, c string, query interface{}) (bool, error)
+	IndexesCtx(ctx context.Context, c string) (indexes []mgo.Index, err error)
+	EnsureIndexCtx(ctx context.Context, c string, index mgo.Index) error
+	EnsureIndexKeyCtx(ctx context.Context, c string, key ...string) error
+	DropIndexCtx(ctx context.Context, c string, key ...string) error
+	DropIndexNameCtx(ctx context.Context, c string, name string) error
+	CreateCollectionCtx(ctx context.Context, c string, info *mgo.CollectionInfo) error
+	DropCollectionCtx(ctx context.Context, c string) error
+	RenameCollectionCtx(ctx context.Context, c string, to string, dropTarget bool) error
+	CollectionStatsCtx(ctx context.Context, c string) (*CollStats, error)
+	ListCollectionsCtx(ctx context.Context) ([]CollectionSpec, error)
+	DropDatabaseCtx(ctx context.Context) error
+	DatabaseStatsCtx(ctx context.Context) (*DBStats, error)
+	ListDatabasesCtx(ctx context.Context) ([]DatabaseSpec, error)
+
+	// For whole document
+	FindOneCtx(ctx context.Context, c string, ret interface{}, query interface{}) (bool, error)
+	FindAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, sort ...string) error
+	FindRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error
+	FindPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error
+	FindDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, key string, sort ...string) error
+	FindIdCtx(ctx context.Context, c string, ret interface{}, id interface{}) (bool, error)
+	// Find And Select
+	SelectOneCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}) (bool, error)
+	SelectAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error
+	SelectRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
+	SelectPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
+	SelectDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error
+	SelectIdCtx(ctx context.Context, c string, ret interface{}, id interface{}, projection interface{}) (bool, error)
+	// Aggregate
+	AggregateCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error
+	AggregateOneCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error)
+	// Iterator, 使用完毕必须Close
+	FindIterCtx(ctx context.Context, c string, query interface{}, batch int, sort ...string) Iter
+	SelectIterCtx(ctx context.Context, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter
+	// Keyset分页, 不使用skip与count
+	FindKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	SelectKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	// FindAndModify
+	FindAndUpdateCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)              // return old doucument
+	FindAndUpsertCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error)   // return old doucument
+	FindAndRemoveCtx(ctx context.Context, c string, ret interface{}, query interface{}) (removed int, err error)                                  // return old doucument
+	FindAndUpdateRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)            // return new doucument
+	FindAndUpsertRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) // return new doucument
+
+	InsertCtx(ctx context.Context, c string, docs ...interface{}) error
+	RemoveOneCtx(ctx context.Context, c string, selector interface{}) (bool, error)
+	RemoveAllCtx(ctx context.Context, c string, selector interface{}) (removed int, err error)
+	RemoveIdCtx(ctx context.Context, c string, id interface{}) (bool, error)
+	UpdateOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (bool, error)
+	UpdateAllCtx(ctx context.Context, c string, selector interface{}, update interface{}) (updated int, err error)
+	UpdateIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (bool, error)
+	UpsertOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (upsertedId interface{}, err error)
+	UpsertIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (upsertedId interface{}, err error)
+	RunBulkCtx(ctx context.Context, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error)
+	RunCollectionCtx(ctx context.Context, c string, f CollectionFunc, args ...interface{}) (interface{}, error)
+
+	DBCountCtx(ctx context.Context, d string, c string) (n int, err error)
+	DBCountWhereCtx(ctx context.Context, d string, c string, query interface{}, opts ...*CountOptions) (n int, err error)
+	DBEstimatedCountCtx(ctx context.Context, d string, c string) (n int, err error)
+	DBExistsCtx(ctx context.Context, d string, c string, query interface{}) (bool, error)
+	DBIndexesCtx(ctx context.Context, d string, c string) (indexes []mgo.Index, err error)
+	DBEnsureIndexCtx(ctx context.Context, d string, c string, index mgo.Index) error
+	DBEnsureIndexKeyCtx(ctx context.Context, d string, c string, key ...string) error
+	DBDropIndexCtx(ctx context.Context, d string, c string, key ...string) error
+	DBDropIndexNameCtx(ctx context.Context, d string, c string, name string) error
+	DBCreateCollectionCtx(ctx context.Context, d string, c string, info *mgo.CollectionInfo) error
+	DBDropCollectionCtx(ctx context.Context, d string, c string) error
+	DBRenameCollectionCtx(ctx context.Context, d string, c string, to string, dropTarget bool) error
+	DBCollectionStatsCtx(ctx context.Context, d string, c string) (*CollStats, error)
+	DBListCollectionsCtx(ctx context.Context, d string) ([]CollectionSpec, error)
+	DBDropDatabaseCtx(ctx context.Context, d string) error
+	DBDatabaseStatsCtx(ctx context.Context, d string) (*DBStats, error)
+
+	// For whole document
+	DBFindOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (bool, error)
+	DBFindAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, sort ...string) error
+	DBFindRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error
+	DBFindPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error
+	DBFindDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string, sort ...string) error
+	DBFindIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}) (bool, error)
+	// Find And Select
+	DBSelectOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (bool, error)
+	DBSelectAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error
+	DBSelectRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
+	DBSelectPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error
+	DBSelectDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error
+	DBSelectIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}, projection interface{}) (bool, error)
+	// Aggregate
+	DBAggregateCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error
+	DBAggregateOneCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error)
+	// Iterator, 使用完毕必须Close
+	DBFindIterCtx(ctx context.Context, d string, c string, query interface{}, batch int, sort ...string) Iter
+	DBSelectIterCtx(ctx context.Context, d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter
+	// Keyset分页, 不使用skip与count
+	DBFindKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	DBSelectKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error)
+	// FindAndModify
+	DBFindAndUpdateCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)              // return old doucument
+	DBFindAndUpsertCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error)   // return old doucument
+	DBFindAndRemoveCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (removed int, err error)                                  // return old doucument
+	DBFindAndUpdateRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error)            // return new doucument
+	DBFindAndUpsertRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) // return new doucument
+
+	DBInsertCtx(ctx context.Context, d string, c string, docs ...interface{}) error
+	DBRemoveOneCtx(ctx context.Context, d string, c string, selector interface{}) (bool, error)
+	DBRemoveAllCtx(ctx context.Context, d string, c string, selector interface{}) (removed int, err error)
+	DBRemoveIdCtx(ctx context.Context, d string, c string, id interface{}) (bool, error)
+	DBUpdateOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (bool, error)
+	DBUpdateAllCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (updated int, err error)
+	DBUpdateIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (bool, error)
+	DBUpsertOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (upsertedId interface{}, err error)
+	DBUpsertIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (upsertedId interface{}, err error)
+	DBRunBulkCtx(ctx context.Context, d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error)
+	DBRunCollectionCtx(ctx context.Context, d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error)
+
+	RunSessionCtx(ctx context.Context, f SessionFunc, args ...interface{}) (interface{}, error)
+
+	Ping(ctx context.Context) error // 检查与服务器的连通性
+	Close() error                   // 拒绝新调用, 等待进行中的调用结束后关闭根会话
 }
 
 func Count(c string) (n int, err error) {
-	return Default.Count(c)
+	return GetDefault().Count(c)
+}
+func CountWhere(c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return GetDefault().CountWhere(c, query, opts...)
+}
+func EstimatedCount(c string) (n int, err error) {
+	return GetDefault().EstimatedCount(c)
+}
+func Exists(c string, query interface{}) (bool, error) {
+	return GetDefault().Exists(c, query)
 }
 func Indexes(c string) (indexes []mgo.Index, err error) {
-	return Default.Indexes(c)
+	return GetDefault().Indexes(c)
 }
 func EnsureIndex(c string, index mgo.Index) error {
-	return Default.EnsureIndex(c, index)
+	return GetDefault().EnsureIndex(c, index)
 }
 func EnsureIndexKey(c string, key ...string) error {
-	return Default.EnsureIndexKey(c, key...)
+	return GetDefault().EnsureIndexKey(c, key...)
 }
 func DropIndex(c string, key ...string) error {
-	return Default.DropIndex(c, key...)
+	return GetDefault().DropIndex(c, key...)
 }
 func DropIndexName(c string, name string) error {
-	return Default.DropIndexName(c, name)
+	return GetDefault().DropIndexName(c, name)
+}
+func CreateCollection(c string, info *mgo.CollectionInfo) error {
+	return GetDefault().CreateCollection(c, info)
+}
+func DropCollection(c string) error {
+	return GetDefault().DropCollection(c)
+}
+func RenameCollection(c string, to string, dropTarget bool) error {
+	return GetDefault().RenameCollection(c, to, dropTarget)
+}
+func CollectionStats(c string) (*CollStats, error) {
+	return GetDefault().CollectionStats(c)
+}
+func ListCollections() ([]CollectionSpec, error) {
+	return GetDefault().ListCollections()
+}
+func DropDatabase() error {
+	return GetDefault().DropDatabase()
+}
+func DatabaseStats() (*DBStats, error) {
+	return GetDefault().DatabaseStats()
+}
+func ListDatabases() ([]DatabaseSpec, error) {
+	return GetDefault().ListDatabases()
 }
 
 func FindOne(c string, ret interface{}, query interface{}) (bool, error) {
-	return Default.FindOne(c, query, ret)
+	return GetDefault().FindOne(c, query, ret)
 }
 func FindAll(c string, ret interface{}, query interface{}, sort ...string) error {
-	return Default.FindAll(c, ret, query, sort...)
+	return GetDefault().FindAll(c, ret, query, sort...)
 }
 func FindRange(c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.FindRange(c, ret, query, skip, limit, sort...)
+	return GetDefault().FindRange(c, ret, query, skip, limit, sort...)
 }
 
 func FindPage(c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.FindPage(c, tot, ret, query, skip, limit, sort...)
+	return GetDefault().FindPage(c, tot, ret, query, skip, limit, sort...)
 }
 
 func FindDistinct(c string, ret interface{}, query interface{}, key string, sort ...string) error {
-	return Default.FindDistinct(c, ret, query, key, sort...)
+	return GetDefault().FindDistinct(c, ret, query, key, sort...)
 }
 func FindId(c string, ret interface{}, id interface{}) (bool, error) {
-	return Default.FindId(c, ret, id)
+	return GetDefault().FindId(c, ret, id)
 }
 
 func SelectOne(c string, ret interface{}, query interface{}, projection interface{}) (bool, error) {
-	return Default.SelectOne(c, ret, query, projection)
+	return GetDefault().SelectOne(c, ret, query, projection)
 }
 func SelectAll(c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
-	return Default.SelectAll(c, ret, query, projection, sort...)
+	return GetDefault().SelectAll(c, ret, query, projection, sort...)
 }
 func SelectRange(c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.SelectRange(c, ret, query, projection, skip, limit, sort...)
+	return GetDefault().SelectRange(c, ret, query, projection, skip, limit, sort...)
 }
 func SelectPage(c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.SelectPage(c, tot, ret, query, projection, skip, limit, sort...)
+	return GetDefault().SelectPage(c, tot, ret, query, projection, skip, limit, sort...)
 }
 func SelectDistinct(c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
-	return Default.SelectDistinct(c, ret, query, projection, key, sort...)
+	return GetDefault().SelectDistinct(c, ret, query, projection, key, sort...)
 }
 func SelectId(c string, ret interface{}, id interface{}, projection interface{}) (bool, error) {
-	return Default.SelectId(c, ret, id, projection)
+	return GetDefault().SelectId(c, ret, id, projection)
+}
+func Aggregate(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return GetDefault().Aggregate(c, ret, pipeline, opts...)
+}
+func AggregateOne(c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error) {
+	return GetDefault().AggregateOne(c, ret, pipeline, opts...)
+}
+func FindIter(c string, query interface{}, batch int, sort ...string) Iter {
+	return GetDefault().FindIter(c, query, batch, sort...)
+}
+func SelectIter(c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return GetDefault().SelectIter(c, query, projection, batch, sort...)
+}
+func FindKeyset(c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().FindKeyset(c, ret, query, token, limit, sort...)
+}
+func SelectKeyset(c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().SelectKeyset(c, ret, query, projection, token, limit, sort...)
 }
 
 func FindAndUpdate(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	return Default.FindAndUpdate(c, ret, query, update)
+	return GetDefault().FindAndUpdate(c, ret, query, update)
 }
 func FindAndUpsert(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	return Default.FindAndUpsert(c, ret, query, upsert)
+	return GetDefault().FindAndUpsert(c, ret, query, upsert)
 }
 func FindAndRemove(c string, ret interface{}, query interface{}) (removed int, err error) {
-	return Default.FindAndRemove(c, ret, query)
+	return GetDefault().FindAndRemove(c, ret, query)
 }
 func FindAndUpdateRN(c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	return Default.FindAndUpdateRN(c, ret, query, update)
+	return GetDefault().FindAndUpdateRN(c, ret, query, update)
 }
 func FindAndUpsertRN(c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	return Default.FindAndUpsertRN(c, ret, query, upsert)
+	return GetDefault().FindAndUpsertRN(c, ret, query, upsert)
 }
 
 func Insert(c string, docs ...interface{}) error {
-	return Default.Insert(c, docs...)
+	return GetDefault().Insert(c, docs...)
 }
 func RemoveOne(c string, selector interface{}) (bool, error) {
-	return Default.RemoveOne(c, selector)
+	return GetDefault().RemoveOne(c, selector)
 }
 func RemoveAll(c string, selector interface{}) (removed int, err error) {
-	return Default.RemoveAll(c, selector)
+	return GetDefault().RemoveAll(c, selector)
 }
 func RemoveId(c string, id interface{}) (bool, error) {
-	return Default.RemoveId(c, id)
+	return GetDefault().RemoveId(c, id)
 }
 func UpdateOne(c string, selector interface{}, update interface{}) (bool, error) {
-	return Default.UpdateOne(c, selector, update)
+	return GetDefault().UpdateOne(c, selector, update)
 }
 func UpdateAll(c string, selector interface{}, update interface{}) (updated int, err error) {
-	return Default.UpdateAll(c, selector, update)
+	return GetDefault().UpdateAll(c, selector, update)
 }
 func UpdateId(c string, id interface{}, update interface{}) (bool, error) {
-	return Default.UpdateId(c, id, update)
+	return GetDefault().UpdateId(c, id, update)
 }
 func UpsertOne(c string, selector interface{}, update interface{}) (upsertedId interface{}, err error) {
-	return Default.UpsertOne(c, selector, update)
+	return GetDefault().UpsertOne(c, selector, update)
 }
 func UpsertId(c string, id interface{}, update interface{}) (upsertedId interface{}, err error) {
-	return Default.UpsertId(c, id, update)
+	return GetDefault().UpsertId(c, id, update)
 }
 func RunBulk(c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
-	return Default.RunBulk(c, f, args...)
+	return GetDefault().RunBulk(c, f, args...)
 }
 
 func RunCollection(c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
-	return Default.RunCollection(c, f, args...)
+	return GetDefault().RunCollection(c, f, args...)
 }
 
 func DBCount(d string, c string) (n int, err error) {
-	return Default.DBCount(d, c)
+	return GetDefault().DBCount(d, c)
+}
+func DBCountWhere(d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return GetDefault().DBCountWhere(d, c, query, opts...)
+}
+func DBEstimatedCount(d string, c string) (n int, err error) {
+	return GetDefault().DBEstimatedCount(d, c)
+}
+func DBExists(d string, c string, query interface{}) (bool, error) {
+	return GetDefault().DBExists(d, c, query)
 }
 func DBIndexes(d string, c string) (indexes []mgo.Index, err error) {
-	return Default.DBIndexes(d, c)
+	return GetDefault().DBIndexes(d, c)
 }
 func DBEnsureIndex(d string, c string, index mgo.Index) error {
-	return Default.DBEnsureIndex(d, c, index)
+	return GetDefault().DBEnsureIndex(d, c, index)
 }
 func DBEnsureIndexKey(d string, c string, key ...string) error {
-	return Default.DBEnsureIndexKey(d, c, key...)
+	return GetDefault().DBEnsureIndexKey(d, c, key...)
 }
 func DBDropIndex(d string, c string, key ...string) error {
-	return Default.DBDropIndex(d, c, key...)
+	return GetDefault().DBDropIndex(d, c, key...)
 }
 func DBDropIndexName(d string, c string, name string) error {
-	return Default.DBDropIndexName(d, c, name)
+	return GetDefault().DBDropIndexName(d, c, name)
+}
+func DBCreateCollection(d string, c string, info *mgo.CollectionInfo) error {
+	return GetDefault().DBCreateCollection(d, c, info)
+}
+func DBDropCollection(d string, c string) error {
+	return GetDefault().DBDropCollection(d, c)
+}
+func DBRenameCollection(d string, c string, to string, dropTarget bool) error {
+	return GetDefault().DBRenameCollection(d, c, to, dropTarget)
+}
+func DBCollectionStats(d string, c string) (*CollStats, error) {
+	return GetDefault().DBCollectionStats(d, c)
+}
+func DBListCollections(d string) ([]CollectionSpec, error) {
+	return GetDefault().DBListCollections(d)
+}
+func DBDropDatabase(d string) error {
+	return GetDefault().DBDropDatabase(d)
+}
+func DBDatabaseStats(d string) (*DBStats, error) {
+	return GetDefault().DBDatabaseStats(d)
 }
 func DBFindOne(d string, c string, ret interface{}, query interface{}) (bool, error) {
-	return Default.DBFindOne(d, c, query, ret)
+	return GetDefault().DBFindOne(d, c, query, ret)
 }
 func DBFindAll(d string, c string, ret interface{}, query interface{}, sort ...string) error {
-	return Default.DBFindAll(d, c, ret, query, sort...)
+	return GetDefault().DBFindAll(d, c, ret, query, sort...)
 }
 func DBFindRange(d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.DBFindRange(d, c, ret, query, skip, limit, sort...)
+	return GetDefault().DBFindRange(d, c, ret, query, skip, limit, sort...)
 }
 
 func DBFindPage(d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.DBFindPage(d, c, tot, ret, query, skip, limit, sort...)
+	return GetDefault().DBFindPage(d, c, tot, ret, query, skip, limit, sort...)
 }
 
 func DBFindDistinct(d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
-	return Default.DBFindDistinct(d, c, ret, query, key, sort...)
+	return GetDefault().DBFindDistinct(d, c, ret, query, key, sort...)
 }
 func DBFindId(d string, c string, ret interface{}, id interface{}) (bool, error) {
-	return Default.DBFindId(d, c, ret, id)
+	return GetDefault().DBFindId(d, c, ret, id)
 }
 
 func DBSelectOne(d string, c string, ret interface{}, query interface{}, projection interface{}) (bool, error) {
-	return Default.DBSelectOne(d, c, ret, query, projection)
+	return GetDefault().DBSelectOne(d, c, ret, query, projection)
 }
 func DBSelectAll(d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
-	return Default.DBSelectAll(d, c, ret, query, projection, sort...)
+	return GetDefault().DBSelectAll(d, c, ret, query, projection, sort...)
 }
 func DBSelectRange(d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.DBSelectRange(d, c, ret, query, projection, skip, limit, sort...)
+	return GetDefault().DBSelectRange(d, c, ret, query, projection, skip, limit, sort...)
 }
 func DBSelectPage(d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
-	return Default.DBSelectPage(d, c, tot, ret, query, projection, skip, limit, sort...)
+	return GetDefault().DBSelectPage(d, c, tot, ret, query, projection, skip, limit, sort...)
 }
 func DBSelectDistinct(d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
-	return Default.DBSelectDistinct(d, c, ret, query, projection, key, sort...)
+	return GetDefault().DBSelectDistinct(d, c, ret, query, projection, key, sort...)
 }
 func DBSelectId(d string, c string, ret interface{}, id interface{}, projection interface{}) (bool, error) {
-	return Default.DBSelectId(d, c, ret, id, projection)
+	return GetDefault().DBSelectId(d, c, ret, id, projection)
+}
+func DBAggregate(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return GetDefault().DBAggregate(d, c, ret, pipeline, opts...)
+}
+func DBAggregateOne(d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error) {
+	return GetDefault().DBAggregateOne(d, c, ret, pipeline, opts...)
+}
+func DBFindIter(d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return GetDefault().DBFindIter(d, c, query, batch, sort...)
+}
+func DBSelectIter(d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return GetDefault().DBSelectIter(d, c, query, projection, batch, sort...)
+}
+func DBFindKeyset(d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().DBFindKeyset(d, c, ret, query, token, limit, sort...)
+}
+func DBSelectKeyset(d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().DBSelectKeyset(d, c, ret, query, projection, token, limit, sort...)
 }
 
 func DBFindAndUpdate(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	return Default.DBFindAndUpdate(d, c, ret, query, update)
+	return GetDefault().DBFindAndUpdate(d, c, ret, query, update)
 }
 func DBFindAndUpsert(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	return Default.DBFindAndUpsert(d, c, ret, query, upsert)
+	return GetDefault().DBFindAndUpsert(d, c, ret, query, upsert)
 }
 func DBFindAndRemove(d string, c string, ret interface{}, query interface{}) (removed int, err error) {
-	return Default.DBFindAndRemove(d, c, ret, query)
+	return GetDefault().DBFindAndRemove(d, c, ret, query)
 }
 func DBFindAndUpdateRN(d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
-	return Default.DBFindAndUpdateRN(d, c, ret, query, update)
+	return GetDefault().DBFindAndUpdateRN(d, c, ret, query, update)
 }
 func DBFindAndUpsertRN(d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
-	return Default.DBFindAndUpsertRN(d, c, ret, query, upsert)
+	return GetDefault().DBFindAndUpsertRN(d, c, ret, query, upsert)
 }
 
 func DBInsert(d string, c string, docs ...interface{}) error {
-	return Default.DBInsert(d, c, docs...)
+	return GetDefault().DBInsert(d, c, docs...)
 }
 func DBRemoveOne(d string, c string, selector interface{}) (bool, error) {
-	return Default.DBRemoveOne(d, c, selector)
+	return GetDefault().DBRemoveOne(d, c, selector)
 }
 func DBRemoveAll(d string, c string, selector interface{}) (removed int, err error) {
-	return Default.DBRemoveAll(d, c, selector)
+	return GetDefault().DBRemoveAll(d, c, selector)
 }
 func DBRemoveId(d string, c string, id interface{}) (bool, error) {
-	return Default.DBRemoveId(d, c, id)
+	return GetDefault().DBRemoveId(d, c, id)
 }
 func DBUpdateOne(d string, c string, selector interface{}, update interface{}) (bool, error) {
-	return Default.DBUpdateOne(d, c, selector, update)
+	return GetDefault().DBUpdateOne(d, c, selector, update)
 }
 func DBUpdateAll(d string, c string, selector interface{}, update interface{}) (updated int, err error) {
-	return Default.DBUpdateAll(d, c, selector, update)
+	return GetDefault().DBUpdateAll(d, c, selector, update)
 }
 func DBUpdateId(d string, c string, id interface{}, update interface{}) (bool, error) {
-	return Default.DBUpdateId(d, c, id, update)
+	return GetDefault().DBUpdateId(d, c, id, update)
 }
 func DBUpsertOne(d string, c string, selector interface{}, update interface{}) (upsertedId interface{}, err error) {
-	return Default.DBUpsertOne(d, c, selector, update)
+	return GetDefault().DBUpsertOne(d, c, selector, update)
 }
 func DBUpsertId(d string, c string, id interface{}, update interface{}) (upsertedId interface{}, err error) {
-	return Default.DBUpsertId(d, c, id, update)
+	return GetDefault().DBUpsertId(d, c, id, update)
 }
 func DBRunBulk(d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
-	return Default.DBRunBulk(d, c, f, args...)
+	return GetDefault().DBRunBulk(d, c, f, args...)
 }
 
 func DBRunCollection(d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
-	return Default.DBRunCollection(d, c, f, args...)
+	return GetDefault().DBRunCollection(d, c, f, args...)
 }
 
 func RunSession(c string, f SessionFunc, args ...interface{}) (interface{}, error) {
-	return Default.RunSession(f, args...)
+	return GetDefault().RunSession(f, args...)
+}
+
+func CountCtx(ctx context.Context, c string) (n int, err error) {
+	return GetDefault().CountCtx(ctx, c)
+}
+func CountWhereCtx(ctx context.Context, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return GetDefault().CountWhereCtx(ctx, c, query, opts...)
+}
+func EstimatedCountCtx(ctx context.Context, c string) (n int, err error) {
+	return GetDefault().EstimatedCountCtx(ctx, c)
+}
+func ExistsCtx(ctx context.Context, c string, query interface{}) (bool, error) {
+	return GetDefault().ExistsCtx(ctx, c, query)
+}
+func IndexesCtx(ctx context.Context, c string) (indexes []mgo.Index, err error) {
+	return GetDefault().IndexesCtx(ctx, c)
+}
+func EnsureIndexCtx(ctx context.Context, c string, index mgo.Index) error {
+	return GetDefault().EnsureIndexCtx(ctx, c, index)
+}
+func EnsureIndexKeyCtx(ctx context.Context, c string, key ...string) error {
+	return GetDefault().EnsureIndexKeyCtx(ctx, c, key...)
+}
+func DropIndexCtx(ctx context.Context, c string, key ...string) error {
+	return GetDefault().DropIndexCtx(ctx, c, key...)
+}
+func DropIndexNameCtx(ctx context.Context, c string, name string) error {
+	return GetDefault().DropIndexNameCtx(ctx, c, name)
+}
+func CreateCollectionCtx(ctx context.Context, c string, info *mgo.CollectionInfo) error {
+	return GetDefault().CreateCollectionCtx(ctx, c, info)
+}
+func DropCollectionCtx(ctx context.Context, c string) error {
+	return GetDefault().DropCollectionCtx(ctx, c)
+}
+func RenameCollectionCtx(ctx context.Context, c string, to string, dropTarget bool) error {
+	return GetDefault().RenameCollectionCtx(ctx, c, to, dropTarget)
+}
+func CollectionStatsCtx(ctx context.Context, c string) (*CollStats, error) {
+	return GetDefault().CollectionStatsCtx(ctx, c)
+}
+func ListCollectionsCtx(ctx context.Context) ([]CollectionSpec, error) {
+	return GetDefault().ListCollectionsCtx(ctx)
+}
+func DropDatabaseCtx(ctx context.Context) error {
+	return GetDefault().DropDatabaseCtx(ctx)
+}
+func DatabaseStatsCtx(ctx context.Context) (*DBStats, error) {
+	return GetDefault().DatabaseStatsCtx(ctx)
+}
+func ListDatabasesCtx(ctx context.Context) ([]DatabaseSpec, error) {
+	return GetDefault().ListDatabasesCtx(ctx)
+}
+
+func FindOneCtx(ctx context.Context, c string, ret interface{}, query interface{}) (bool, error) {
+	return GetDefault().FindOneCtx(ctx, c, ret, query)
+}
+func FindAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, sort ...string) error {
+	return GetDefault().FindAllCtx(ctx, c, ret, query, sort...)
+}
+func FindRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().FindRangeCtx(ctx, c, ret, query, skip, limit, sort...)
+}
+
+func FindPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().FindPageCtx(ctx, c, tot, ret, query, skip, limit, sort...)
+}
+
+func FindDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return GetDefault().FindDistinctCtx(ctx, c, ret, query, key, sort...)
+}
+func FindIdCtx(ctx context.Context, c string, ret interface{}, id interface{}) (bool, error) {
+	return GetDefault().FindIdCtx(ctx, c, ret, id)
+}
+
+func SelectOneCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}) (bool, error) {
+	return GetDefault().SelectOneCtx(ctx, c, ret, query, projection)
+}
+func SelectAllCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return GetDefault().SelectAllCtx(ctx, c, ret, query, projection, sort...)
+}
+func SelectRangeCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().SelectRangeCtx(ctx, c, ret, query, projection, skip, limit, sort...)
+}
+func SelectPageCtx(ctx context.Context, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().SelectPageCtx(ctx, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func SelectDistinctCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return GetDefault().SelectDistinctCtx(ctx, c, ret, query, projection, key, sort...)
+}
+func SelectIdCtx(ctx context.Context, c string, ret interface{}, id interface{}, projection interface{}) (bool, error) {
+	return GetDefault().SelectIdCtx(ctx, c, ret, id, projection)
+}
+func AggregateCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return GetDefault().AggregateCtx(ctx, c, ret, pipeline, opts...)
+}
+func AggregateOneCtx(ctx context.Context, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error) {
+	return GetDefault().AggregateOneCtx(ctx, c, ret, pipeline, opts...)
+}
+func FindIterCtx(ctx context.Context, c string, query interface{}, batch int, sort ...string) Iter {
+	return GetDefault().FindIterCtx(ctx, c, query, batch, sort...)
+}
+func SelectIterCtx(ctx context.Context, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return GetDefault().SelectIterCtx(ctx, c, query, projection, batch, sort...)
+}
+func FindKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().FindKeysetCtx(ctx, c, ret, query, token, limit, sort...)
+}
+func SelectKeysetCtx(ctx context.Context, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().SelectKeysetCtx(ctx, c, ret, query, projection, token, limit, sort...)
+}
+
+func FindAndUpdateCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().FindAndUpdateCtx(ctx, c, ret, query, update)
+}
+func FindAndUpsertCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().FindAndUpsertCtx(ctx, c, ret, query, upsert)
+}
+func FindAndRemoveCtx(ctx context.Context, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return GetDefault().FindAndRemoveCtx(ctx, c, ret, query)
+}
+func FindAndUpdateRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().FindAndUpdateRNCtx(ctx, c, ret, query, update)
+}
+func FindAndUpsertRNCtx(ctx context.Context, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().FindAndUpsertRNCtx(ctx, c, ret, query, upsert)
+}
+
+func InsertCtx(ctx context.Context, c string, docs ...interface{}) error {
+	return GetDefault().InsertCtx(ctx, c, docs...)
+}
+func RemoveOneCtx(ctx context.Context, c string, selector interface{}) (bool, error) {
+	return GetDefault().RemoveOneCtx(ctx, c, selector)
+}
+func RemoveAllCtx(ctx context.Context, c string, selector interface{}) (removed int, err error) {
+	return GetDefault().RemoveAllCtx(ctx, c, selector)
+}
+func RemoveIdCtx(ctx context.Context, c string, id interface{}) (bool, error) {
+	return GetDefault().RemoveIdCtx(ctx, c, id)
+}
+func UpdateOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (bool, error) {
+	return GetDefault().UpdateOneCtx(ctx, c, selector, update)
+}
+func UpdateAllCtx(ctx context.Context, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().UpdateAllCtx(ctx, c, selector, update)
+}
+func UpdateIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (bool, error) {
+	return GetDefault().UpdateIdCtx(ctx, c, id, update)
+}
+func UpsertOneCtx(ctx context.Context, c string, selector interface{}, update interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().UpsertOneCtx(ctx, c, selector, update)
+}
+func UpsertIdCtx(ctx context.Context, c string, id interface{}, update interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().UpsertIdCtx(ctx, c, id, update)
+}
+func RunBulkCtx(ctx context.Context, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return GetDefault().RunBulkCtx(ctx, c, f, args...)
+}
+
+func RunCollectionCtx(ctx context.Context, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return GetDefault().RunCollectionCtx(ctx, c, f, args...)
+}
+
+func DBCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	return GetDefault().DBCountCtx(ctx, d, c)
+}
+func DBCountWhereCtx(ctx context.Context, d string, c string, query interface{}, opts ...*CountOptions) (n int, err error) {
+	return GetDefault().DBCountWhereCtx(ctx, d, c, query, opts...)
+}
+func DBEstimatedCountCtx(ctx context.Context, d string, c string) (n int, err error) {
+	return GetDefault().DBEstimatedCountCtx(ctx, d, c)
+}
+func DBExistsCtx(ctx context.Context, d string, c string, query interface{}) (bool, error) {
+	return GetDefault().DBExistsCtx(ctx, d, c, query)
+}
+func DBIndexesCtx(ctx context.Context, d string, c string) (indexes []mgo.Index, err error) {
+	return GetDefault().DBIndexesCtx(ctx, d, c)
+}
+func DBEnsureIndexCtx(ctx context.Context, d string, c string, index mgo.Index) error {
+	return GetDefault().DBEnsureIndexCtx(ctx, d, c, index)
+}
+func DBEnsureIndexKeyCtx(ctx context.Context, d string, c string, key ...string) error {
+	return GetDefault().DBEnsureIndexKeyCtx(ctx, d, c, key...)
+}
+func DBDropIndexCtx(ctx context.Context, d string, c string, key ...string) error {
+	return GetDefault().DBDropIndexCtx(ctx, d, c, key...)
+}
+func DBDropIndexNameCtx(ctx context.Context, d string, c string, name string) error {
+	return GetDefault().DBDropIndexNameCtx(ctx, d, c, name)
+}
+func DBCreateCollectionCtx(ctx context.Context, d string, c string, info *mgo.CollectionInfo) error {
+	return GetDefault().DBCreateCollectionCtx(ctx, d, c, info)
+}
+func DBDropCollectionCtx(ctx context.Context, d string, c string) error {
+	return GetDefault().DBDropCollectionCtx(ctx, d, c)
+}
+func DBRenameCollectionCtx(ctx context.Context, d string, c string, to string, dropTarget bool) error {
+	return GetDefault().DBRenameCollectionCtx(ctx, d, c, to, dropTarget)
+}
+func DBCollectionStatsCtx(ctx context.Context, d string, c string) (*CollStats, error) {
+	return GetDefault().DBCollectionStatsCtx(ctx, d, c)
+}
+func DBListCollectionsCtx(ctx context.Context, d string) ([]CollectionSpec, error) {
+	return GetDefault().DBListCollectionsCtx(ctx, d)
+}
+func DBDropDatabaseCtx(ctx context.Context, d string) error {
+	return GetDefault().DBDropDatabaseCtx(ctx, d)
+}
+func DBDatabaseStatsCtx(ctx context.Context, d string) (*DBStats, error) {
+	return GetDefault().DBDatabaseStatsCtx(ctx, d)
+}
+func DBFindOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (bool, error) {
+	return GetDefault().DBFindOneCtx(ctx, d, c, ret, query)
+}
+func DBFindAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, sort ...string) error {
+	return GetDefault().DBFindAllCtx(ctx, d, c, ret, query, sort...)
+}
+func DBFindRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().DBFindRangeCtx(ctx, d, c, ret, query, skip, limit, sort...)
+}
+
+func DBFindPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().DBFindPageCtx(ctx, d, c, tot, ret, query, skip, limit, sort...)
+}
+
+func DBFindDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, key string, sort ...string) error {
+	return GetDefault().DBFindDistinctCtx(ctx, d, c, ret, query, key, sort...)
+}
+func DBFindIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}) (bool, error) {
+	return GetDefault().DBFindIdCtx(ctx, d, c, ret, id)
+}
+
+func DBSelectOneCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}) (bool, error) {
+	return GetDefault().DBSelectOneCtx(ctx, d, c, ret, query, projection)
+}
+func DBSelectAllCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, sort ...string) error {
+	return GetDefault().DBSelectAllCtx(ctx, d, c, ret, query, projection, sort...)
+}
+func DBSelectRangeCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().DBSelectRangeCtx(ctx, d, c, ret, query, projection, skip, limit, sort...)
+}
+func DBSelectPageCtx(ctx context.Context, d string, c string, tot *uint32, ret interface{}, query interface{}, projection interface{}, skip uint32, limit uint32, sort ...string) error {
+	return GetDefault().DBSelectPageCtx(ctx, d, c, tot, ret, query, projection, skip, limit, sort...)
+}
+func DBSelectDistinctCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, key string, sort ...string) error {
+	return GetDefault().DBSelectDistinctCtx(ctx, d, c, ret, query, projection, key, sort...)
+}
+func DBSelectIdCtx(ctx context.Context, d string, c string, ret interface{}, id interface{}, projection interface{}) (bool, error) {
+	return GetDefault().DBSelectIdCtx(ctx, d, c, ret, id, projection)
+}
+func DBAggregateCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) error {
+	return GetDefault().DBAggregateCtx(ctx, d, c, ret, pipeline, opts...)
+}
+func DBAggregateOneCtx(ctx context.Context, d string, c string, ret interface{}, pipeline interface{}, opts ...*PipeOptions) (bool, error) {
+	return GetDefault().DBAggregateOneCtx(ctx, d, c, ret, pipeline, opts...)
+}
+func DBFindIterCtx(ctx context.Context, d string, c string, query interface{}, batch int, sort ...string) Iter {
+	return GetDefault().DBFindIterCtx(ctx, d, c, query, batch, sort...)
+}
+func DBSelectIterCtx(ctx context.Context, d string, c string, query interface{}, projection interface{}, batch int, sort ...string) Iter {
+	return GetDefault().DBSelectIterCtx(ctx, d, c, query, projection, batch, sort...)
+}
+func DBFindKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().DBFindKeysetCtx(ctx, d, c, ret, query, token, limit, sort...)
+}
+func DBSelectKeysetCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, projection interface{}, token string, limit uint32, sort ...string) (next string, prev string, err error) {
+	return GetDefault().DBSelectKeysetCtx(ctx, d, c, ret, query, projection, token, limit, sort...)
+}
+
+func DBFindAndUpdateCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().DBFindAndUpdateCtx(ctx, d, c, ret, query, update)
+}
+func DBFindAndUpsertCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().DBFindAndUpsertCtx(ctx, d, c, ret, query, upsert)
+}
+func DBFindAndRemoveCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}) (removed int, err error) {
+	return GetDefault().DBFindAndRemoveCtx(ctx, d, c, ret, query)
+}
+func DBFindAndUpdateRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().DBFindAndUpdateRNCtx(ctx, d, c, ret, query, update)
+}
+func DBFindAndUpsertRNCtx(ctx context.Context, d string, c string, ret interface{}, query interface{}, upsert interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().DBFindAndUpsertRNCtx(ctx, d, c, ret, query, upsert)
+}
+
+func DBInsertCtx(ctx context.Context, d string, c string, docs ...interface{}) error {
+	return GetDefault().DBInsertCtx(ctx, d, c, docs...)
+}
+func DBRemoveOneCtx(ctx context.Context, d string, c string, selector interface{}) (bool, error) {
+	return GetDefault().DBRemoveOneCtx(ctx, d, c, selector)
+}
+func DBRemoveAllCtx(ctx context.Context, d string, c string, selector interface{}) (removed int, err error) {
+	return GetDefault().DBRemoveAllCtx(ctx, d, c, selector)
+}
+func DBRemoveIdCtx(ctx context.Context, d string, c string, id interface{}) (bool, error) {
+	return GetDefault().DBRemoveIdCtx(ctx, d, c, id)
+}
+func DBUpdateOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (bool, error) {
+	return GetDefault().DBUpdateOneCtx(ctx, d, c, selector, update)
+}
+func DBUpdateAllCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (updated int, err error) {
+	return GetDefault().DBUpdateAllCtx(ctx, d, c, selector, update)
+}
+func DBUpdateIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (bool, error) {
+	return GetDefault().DBUpdateIdCtx(ctx, d, c, id, update)
+}
+func DBUpsertOneCtx(ctx context.Context, d string, c string, selector interface{}, update interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().DBUpsertOneCtx(ctx, d, c, selector, update)
+}
+func DBUpsertIdCtx(ctx context.Context, d string, c string, id interface{}, update interface{}) (upsertedId interface{}, err error) {
+	return GetDefault().DBUpsertIdCtx(ctx, d, c, id, update)
+}
+func DBRunBulkCtx(ctx context.Context, d string, c string, f BulkFunc, args ...interface{}) (matched int, modified int, err error) {
+	return GetDefault().DBRunBulkCtx(ctx, d, c, f, args...)
+}
+
+func DBRunCollectionCtx(ctx context.Context, d string, c string, f CollectionFunc, args ...interface{}) (interface{}, error) {
+	return GetDefault().DBRunCollectionCtx(ctx, d, c, f, args...)
+}
+
+func RunSessionCtx(ctx context.Context, f SessionFunc, args ...interface{}) (interface{}, error) {
+	return GetDefault().RunSessionCtx(ctx, f, args...)
+}
+
+func Ping(ctx context.Context) error {
+	return GetDefault().Ping(ctx)
 }
 
 type Config struct {
-	// 连接URL, 格式为[mongodb://][user:pass@]host1[:port1][,host2[:port2],...][/database][?options]
-	Address  []string
-	Database string
-	Username string
-	Password string
-	Source   string
-	Safe     *mgo.Safe
-	Mode     mgo.Mode
+	// 连接URL, 格式为mongodb://[user:pass@]host1[:port1][,host2[:port2],...][/database][?options]
+	// 解析到以下各字段, 已设置(非零值)的字段优先. options支持replicaSet, authSource, readPreference, w, wtimeoutMS, journal, maxPoolSize, connectTimeoutMS
+	URI        string
+	Address    []string
+	Database   string
+	Username   string
+	Password   string
+	Source     string
+	ReplicaSet string // 副本集名称, 指定后只连接该副本集的成员
+	Safe       *mgo.Safe
+	Mode       mgo.Mode
 
 	// 连接管理
 	ConnectTimeout time.Duration //连接超时. 默认为10秒
@@ -348,24 +991,65 @@ type Config struct {
 	MaxPoolSize       int //对应DialInfo.PoolLimit
 	MaxPoolWaitTimeMS int //对应DialInfo.PoolTimeout获取连接超时, 默认为0永不超时
 	MaxPoolIdleTimeMS int //对应DialInfo.MaxIdleTimeMS
+
+	// TLS连接选项, nil不使用TLS
+	TLS *TLSOptions
+
+	// 纯内存实现, 不连接服务器, 用于单元测试
+	Memory bool
+	// 延迟连接: Setup时不连接, 首次使用时连接, 失败后在后台重连
+	Lazy bool
+	// 严格模式: Update*/FindAndUpdate*/Upsert*的update必须全部为操作符, 整体替换需用Replace()包装
+	Strict bool
+
+	// 拦截器, 按顺序包装所有操作, 第一个在最外层
+	Interceptors []Interceptor
+	// 统计操作次数与延迟, 由MetricsHandler导出
+	Metrics bool
+
+	// 慢查询日志
+	SlowQueryThreshold time.Duration   // 耗时超过阈值的操作写入日志, 0不记录
+	RedactFields       []string        // 日志中隐藏这些字段的值
+	SlowQueryLogger    SlowQueryLogger // 默认使用标准库log
+
+	// 重试策略, nil不重试
+	Retry *RetryPolicy
+	// 熔断策略, nil不熔断
+	Breaker *BreakerPolicy
+
+	problems   []string // 解析conf.yml或URI时发现的问题, 由Validate报告
+	uriApplied bool
 }
 
+/*
+Default及Clients是默认注册表的只读快照, 每次Setup, RegisterClient, ReplaceClient等修改后整体更新.
+并发读取请使用GetDefault, Get及ListClients, 修改请使用RegisterClient, UnregisterClient, ReplaceClient及SetDefault
+*/
 var (
 	Default Mongo
 	Clients map[string]Mongo = make(map[string]Mongo)
 )
 
 func Get(name string) Mongo {
-	if rt, ok := Clients[name]; ok {
-		return rt
+	return defaultRegistry.Get(name)
+}
+
+// clientLabel 实例在指标及日志中的标识, 多个key用逗号连接
+func clientLabel(keys []string) string {
+	var ret []string
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); len(k) > 0 {
+			ret = append(ret, k)
+		}
 	}
-	return nil
+	return strings.Join(ret, ",")
 }
 
 func mergeOption(opt *Config) *Config {
 	if opt == nil {
 		opt = new(Config)
 	}
+	opt.applyURI()
 	if opt.Safe == nil {
 		opt.Safe = &mgo.Safe{
 			WMode: Safe_majority,
@@ -375,51 +1059,87 @@ func mergeOption(opt *Config) *Config {
 	return opt
 }
 
-func Setup(name string, opt *Config, def bool) (err error) {
+// Setup 注册实例, interceptors追加在Config.Interceptors之后
+func Setup(name string, opt *Config, def bool, interceptors ...Interceptor) (err error) {
 
 	keys := strings.Split(name, ",")
-	for _, k := range keys {
-		if _, ok := Clients[k]; ok {
-			err = errors.New("duplicate clickhouse key " + k)
-			return
-		}
+	if err = defaultRegistry.check(defaultRegistry.snapshot(), keys); err != nil {
+		return
 	}
-
-	m, err := newGlobalsignMongo(mergeOption(opt))
+	m, err := newClient(keys, opt, interceptors)
 	if err != nil {
 		return
 	}
-	for _, k := range keys {
-		if k = strings.TrimSpace(k); len(k) > 0 {
-			Clients[k] = m
+	if err = defaultRegistry.add(keys, m, def); err != nil {
+		m.Close() // 并发Setup相同key
+	}
+	return
+}
+
+// newClient 检查配置, 创建实例并按配置组装拦截器链, 不注册
+func newClient(keys []string, opt *Config, interceptors []Interceptor) (m Mongo, err error) {
+	opt = mergeOption(opt)
+	if err = opt.Validate(); err != nil {
+		return
+	}
+	if opt.Memory {
+		m = newMemoryMongo(opt)
+	} else if m, err = newGlobalsignMongo(opt); err != nil {
+		return
+	}
+	var its []Interceptor
+	if opt.Metrics {
+		its = append(its, defaultMetrics.register(clientLabel(keys), opt))
+	}
+	if opt.SlowQueryThreshold > 0 {
+		its = append(its, slowQueryInterceptor(clientLabel(keys), opt))
+	}
+	if opt.Breaker != nil {
+		its = append(its, newBreaker(clientLabel(keys), opt.Breaker).interceptor())
+	}
+	if opt.Retry != nil && opt.Retry.Attempts > 1 {
+		var refresh func()
+		if r, ok := m.(interface{ Refresh() }); ok {
+			refresh = r.Refresh // 刷新根会话, 丢弃可能已失效的socket并重新选择主节点
 		}
+		its = append(its, retryInterceptor(opt.Retry, refresh))
 	}
-	if def {
-		Default = m
+	its = append(its, opt.Interceptors...)
+	if its = append(its, interceptors...); len(its) > 0 {
+		m = newInterceptMongo(opt, m, its)
 	}
 	return
 }
 
+// GetMode 未知的name会panic, 不希望panic时使用ParseMode
 func GetMode(name string) mgo.Mode {
+	mode, err := ParseMode(name)
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func ParseMode(name string) (mgo.Mode, error) {
 	switch name {
 	case "Primary", "primary":
-		return mgo.Primary
+		return mgo.Primary, nil
 	case "PrimaryPreferred", "primaryPreferred":
-		return mgo.PrimaryPreferred
+		return mgo.PrimaryPreferred, nil
 	case "Secondary", "secondary":
-		return mgo.Secondary
+		return mgo.Secondary, nil
 	case "SecondaryPreferred", "secondaryPreferred":
-		return mgo.SecondaryPreferred
+		return mgo.SecondaryPreferred, nil
 	case "Nearest", "nearest":
-		return mgo.Nearest
+		return mgo.Nearest, nil
 	case "Eventual", "eventual":
-		return mgo.Eventual
+		return mgo.Eventual, nil
 	case "Monotonic", "monotonic":
-		return mgo.Monotonic
+		return mgo.Monotonic, nil
 	case "Strong", "strong":
-		return mgo.Strong
+		return mgo.Strong, nil
 	}
-	panic("Invalid mode name: " + name)
+	return 0, errors.New("Invalid mode name: " + name)
 }
 
 const (
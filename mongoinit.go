@@ -1,99 +1,348 @@
 package mongo
 
 import (
+	"errors"
 	"fmt"
 	"github.com/globalsign/mgo"
 	"github.com/obase/conf"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 const CKEY = "mongo"
 
-// 对接conf.yml, 读取原redis相关配置
+// 对接conf.yml, 读取mongo相关配置. 出错时不panic, 错误输出到stderr并可通过InitFromConf获取
 func init() {
-
-	configs, ok := conf.GetSlice(CKEY)
-	if !ok || len(configs) == 0 {
-		return
+	if err := InitFromConf(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 	}
+}
 
-	for _, config := range configs {
-		if key, ok := conf.ElemString(config, "key"); ok {
-			address, ok := conf.ElemStringSlice(config, "address")
-			database, ok := conf.ElemString(config, "database")
-			username, ok := conf.ElemString(config, "username")
-			password, ok := conf.ElemString(config, "password")
-			source, ok := conf.ElemString(config, "source")
-			safe, ok := conf.ElemMap(config, "safe")
-			mode, ok := conf.Elem(config, "mode")
+var initConf struct {
+	sync.Once
+	err error
+}
 
-			keepalive, ok := conf.ElemDuration(config, "keepalive")
-			if !ok {
-				keepalive = time.Minute
-			}
-			connectTimeout, ok := conf.ElemDuration(config, "connectTimeout")
-			if !ok {
-				connectTimeout = 30 * time.Second
+/*
+InitFromConf 按conf.yml的mongo配置注册实例, 只执行一次, 重复调用返回首次的结果:
+1. 某个实例出错不影响其余实例, 返回汇总的所有错误
+2. lazy: true的实例不在此时连接, 不会因服务器暂时不可达而出错
+包的init已调用, main中再次调用可获取错误并决定是否退出
+*/
+func InitFromConf() error {
+	initConf.Do(func() {
+		configs, _ := conf.GetSlice(CKEY)
+		var errs []string
+		for i, config := range configs {
+			key, option, defalt, err := getConfig(config)
+			if err == nil {
+				err = Setup(key, option, defalt)
 			}
-			readTimeout, ok := conf.ElemDuration(config, "readTimeout")
-			if !ok {
-				readTimeout = 30 * time.Second
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v[%v]%v: %v", CKEY, i, key, err))
+				continue
 			}
-			writeTimeout, ok := conf.ElemDuration(config, "writeTimeout")
-			if !ok {
-				writeTimeout = 30 * time.Second
-			}
-			minPoolSize, ok := conf.ElemInt(config, "minPoolSize")
-			maxPoolSize, ok := conf.ElemInt(config, "maxPoolSize")
-			if !ok {
-				maxPoolSize = 16
+			loaded.set(key, option, defalt)
+		}
+		if len(errs) > 0 {
+			initConf.err = errors.New("mongo init: " + strings.Join(errs, "; "))
+		}
+	})
+	return initConf.err
+}
+
+// confKeys conf.yml中实例配置的所有key
+var confKeys = []string{
+	"key", "uri", "uriFile", "address", "database", "username", "password", "passwordFile", "source", "replicaSet", "safe", "mode",
+	"keepalive", "connectTimeout", "readTimeout", "writeTimeout",
+	"minPoolSize", "maxPoolSize", "maxPoolWaitTimeMS", "maxPoolIdleTimeMS",
+	"memory", "lazy", "strict", "metrics", "slowQueryThreshold", "redactFields", "retry", "breaker", "tls", "default",
+}
+
+// confParser 读取单个实例配置, 类型错误及未知key记录到problems而不是panic
+type confParser struct {
+	config   interface{}
+	problems []string
+}
+
+func (p *confParser) addf(format string, args ...interface{}) {
+	p.problems = append(p.problems, fmt.Sprintf(format, args...))
+}
+
+// call 执行f, 将conf.ToXxx的panic记录为key的错误
+func (p *confParser) call(key string, f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.addf("invalid %v: %v", key, r)
+		}
+	}()
+	f()
+}
+
+// unknown 检查未知key, 大小写或多一个字符的差异给出提示(如model/mode)
+func (p *confParser) unknown(prefix string, val interface{}, known []string) {
+	var keys []string
+	switch val := val.(type) {
+	case map[interface{}]interface{}:
+		for k := range val {
+			keys = append(keys, fmt.Sprint(k))
+		}
+	case map[string]interface{}:
+		for k := range val {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+NEXT:
+	for _, k := range keys {
+		for _, n := range known {
+			if k == n {
+				continue NEXT
 			}
-			maxPoolWaitTimeMS, ok := conf.ElemInt(config, "maxPoolWaitTimeMS")
-			maxPoolIdleTimeMS, ok := conf.ElemInt(config, "maxPoolIdleTimeMS")
-
-			defalt, ok := conf.ElemBool(config, "default")
-
-			option := &Config{
-				Address:           address,
-				Database:          database,
-				Username:          username,
-				Password:          password,
-				Source:            source,
-				Safe:              getSafe(safe),
-				Mode:              getMode(mode),
-				ConnectTimeout:    connectTimeout,
-				Keepalive:         keepalive,
-				WriteTimeout:      writeTimeout,
-				ReadTimeout:       readTimeout,
-				MinPoolSize:       minPoolSize,
-				MaxPoolSize:       maxPoolSize,
-				MaxPoolWaitTimeMS: maxPoolWaitTimeMS,
-				MaxPoolIdleTimeMS: maxPoolIdleTimeMS,
+		}
+		for _, n := range known {
+			if strings.EqualFold(k, n) || len(k) == len(n)+1 && strings.HasPrefix(k, n) || len(n) == len(k)+1 && strings.HasPrefix(n, k) {
+				p.addf("unknown key %q (did you mean %q?)", prefix+k, prefix+n)
+				continue NEXT
 			}
+		}
+		p.addf("unknown key %q", prefix+k)
+	}
+}
 
-			if err := Setup(key, option, defalt); err != nil {
-				panic(err)
-			}
+/*
+getConfig 解析conf.yml中的单个实例配置, 缺少key时返回错误, 其余问题由Config.Validate报告.
+除key外的每一项都可由环境变量MONGO_<KEY>_<FIELD>覆盖, 见envName
+*/
+func getConfig(config interface{}) (key string, option *Config, defalt bool, err error) {
+	p := &confParser{config: config}
+	var ok bool
+	p.call("key", func() { key, ok = conf.ElemString(config, "key") })
+	if !ok || strings.TrimSpace(key) == "" {
+		err = errors.New("missing key")
+		return
+	}
+	p.unknown("", config, confKeys)
+	config = p.overrideEnv(key, config)
+	p.config = config
+
+	var (
+		address                              []string
+		uri, database, username, password    string
+		uriFile, passwordFile                string
+		source, replicaSet                   string
+		safe, retry, breaker, tlsOptions     map[string]interface{}
+		mode                                 interface{}
+		keepalive, connectTimeout            time.Duration
+		readTimeout, writeTimeout            time.Duration
+		minPoolSize, maxPoolSize             int
+		maxPoolWaitTimeMS, maxPoolIdleTimeMS int
+		memory, lazy, strict, metrics        bool
+		slowQueryThreshold                   time.Duration
+		redactFields                         []string
+	)
+	p.call("uri", func() { uri, _ = conf.ElemString(config, "uri") })
+	p.call("uriFile", func() { uriFile, _ = conf.ElemString(config, "uriFile") })
+	p.call("passwordFile", func() { passwordFile, _ = conf.ElemString(config, "passwordFile") })
+	p.call("address", func() { address, _ = conf.ElemStringSlice(config, "address") })
+	p.call("database", func() { database, _ = conf.ElemString(config, "database") })
+	p.call("username", func() { username, _ = conf.ElemString(config, "username") })
+	p.call("password", func() { password, _ = conf.ElemString(config, "password") })
+	p.call("source", func() { source, _ = conf.ElemString(config, "source") })
+	p.call("replicaSet", func() { replicaSet, _ = conf.ElemString(config, "replicaSet") })
+	p.call("safe", func() { safe, _ = conf.ElemMap(config, "safe") })
+	mode, _ = conf.Elem(config, "mode")
+
+	if p.call("keepalive", func() { keepalive, ok = conf.ElemDuration(config, "keepalive") }); !ok {
+		keepalive = time.Minute
+	}
+	p.call("connectTimeout", func() { connectTimeout, _ = conf.ElemDuration(config, "connectTimeout") })
+	if p.call("readTimeout", func() { readTimeout, ok = conf.ElemDuration(config, "readTimeout") }); !ok {
+		readTimeout = 30 * time.Second
+	}
+	if p.call("writeTimeout", func() { writeTimeout, ok = conf.ElemDuration(config, "writeTimeout") }); !ok {
+		writeTimeout = 30 * time.Second
+	}
+	p.call("minPoolSize", func() { minPoolSize, _ = conf.ElemInt(config, "minPoolSize") })
+	p.call("maxPoolSize", func() { maxPoolSize, _ = conf.ElemInt(config, "maxPoolSize") })
+	p.call("maxPoolWaitTimeMS", func() { maxPoolWaitTimeMS, _ = conf.ElemInt(config, "maxPoolWaitTimeMS") })
+	p.call("maxPoolIdleTimeMS", func() { maxPoolIdleTimeMS, _ = conf.ElemInt(config, "maxPoolIdleTimeMS") })
+
+	p.call("memory", func() { memory, _ = conf.ElemBool(config, "memory") })
+	p.call("lazy", func() { lazy, _ = conf.ElemBool(config, "lazy") })
+	p.call("strict", func() { strict, _ = conf.ElemBool(config, "strict") })
+	p.call("metrics", func() { metrics, _ = conf.ElemBool(config, "metrics") })
+	p.call("slowQueryThreshold", func() { slowQueryThreshold, _ = conf.ElemDuration(config, "slowQueryThreshold") })
+	p.call("redactFields", func() { redactFields, _ = conf.ElemStringSlice(config, "redactFields") })
+	p.call("retry", func() { retry, _ = conf.ElemMap(config, "retry") })
+	p.call("breaker", func() { breaker, _ = conf.ElemMap(config, "breaker") })
+	p.call("tls", func() { tlsOptions, _ = conf.ElemMap(config, "tls") })
+
+	p.call("default", func() { defalt, _ = conf.ElemBool(config, "default") })
+
+	// secret文件优先于uri及password, 热加载时重新读取
+	if uriFile != "" {
+		if uri, err = readSecret(uriFile); err != nil {
+			p.addf("uriFile: %v", err)
+		}
+	}
+	if passwordFile != "" {
+		if password, err = readSecret(passwordFile); err != nil {
+			p.addf("passwordFile: %v", err)
 		}
 	}
+	err = nil
+
+	mgoMode, merr := getMode(mode)
+	if merr != nil {
+		p.addf("invalid mode: %v", mode)
+	}
+	option = &Config{
+		URI:                uri,
+		Address:            address,
+		Database:           database,
+		Username:           username,
+		Password:           password,
+		Source:             source,
+		ReplicaSet:         replicaSet,
+		Mode:               mgoMode,
+		ConnectTimeout:     connectTimeout,
+		Keepalive:          keepalive,
+		WriteTimeout:       writeTimeout,
+		ReadTimeout:        readTimeout,
+		MinPoolSize:        minPoolSize,
+		MaxPoolSize:        maxPoolSize,
+		MaxPoolWaitTimeMS:  maxPoolWaitTimeMS,
+		MaxPoolIdleTimeMS:  maxPoolIdleTimeMS,
+		Memory:             memory,
+		Lazy:               lazy,
+		Strict:             strict,
+		Metrics:            metrics,
+		SlowQueryThreshold: slowQueryThreshold,
+		RedactFields:       redactFields,
+	}
+	if _, ok = conf.Elem(config, "safe"); ok || uri == "" {
+		p.call("safe", func() { option.Safe = getSafe(safe, p.unknownSub("safe.")) })
+	}
+	p.call("retry", func() { option.Retry = getRetry(retry, p.unknownSub("retry.")) })
+	p.call("breaker", func() { option.Breaker = getBreaker(breaker, p.unknownSub("breaker.")) })
+	p.call("tls", func() { option.TLS = getTLS(tlsOptions, p.unknownSub("tls.")) })
+
+	// 显式配置 > uri > 默认值
+	option.applyURI()
+	option.problems = append(p.problems, option.problems...)
+	if option.Safe == nil {
+		option.Safe = getSafe(nil, nil)
+	}
+	if _, ok = conf.Elem(config, "mode"); ok {
+		option.Mode = mgoMode
+	}
+	if _, ok = conf.Elem(config, "connectTimeout"); !ok && option.ConnectTimeout == 0 {
+		option.ConnectTimeout = 30 * time.Second
+	}
+	if _, ok = conf.Elem(config, "maxPoolSize"); !ok && option.MaxPoolSize == 0 {
+		option.MaxPoolSize = 16
+	}
+	return
 }
 
-func getMode(val interface{}) mgo.Mode {
+func (p *confParser) unknownSub(prefix string) func(key string) {
+	return func(key string) {
+		p.addf("unknown key %q", prefix+key)
+	}
+}
+
+func getMode(val interface{}) (mgo.Mode, error) {
 	switch val := val.(type) {
 	case nil:
-		return mgo.Eventual //默认返回最终一致
+		return mgo.Eventual, nil //默认返回最终一致
 	case string:
-		return GetMode(val)
+		return ParseMode(val)
 	case int:
-		return mgo.Mode(val)
+		return mgo.Mode(val), nil
 	case uint:
-		return mgo.Mode(val)
+		return mgo.Mode(val), nil
 	default:
-		return GetMode(fmt.Sprintf("%v", val))
+		return ParseMode(fmt.Sprintf("%v", val))
+	}
+}
+
+func getRetry(val map[string]interface{}, unknown func(key string)) *RetryPolicy {
+	if len(val) == 0 {
+		return nil
+	}
+	retry := new(RetryPolicy)
+	for k, v := range val {
+		switch k {
+		case "attempts":
+			retry.Attempts = conf.ToInt(v)
+		case "backoff":
+			retry.Backoff = conf.ToDuration(v)
+		case "maxBackoff":
+			retry.MaxBackoff = conf.ToDuration(v)
+		case "jitter":
+			retry.Jitter = conf.ToFloat64(v)
+		case "errors":
+			retry.Errors = conf.ToStringSlice(v)
+		default:
+			unknown(k)
+		}
+	}
+	return retry
+}
+
+func getBreaker(val map[string]interface{}, unknown func(key string)) *BreakerPolicy {
+	if len(val) == 0 {
+		return nil
+	}
+	breaker := new(BreakerPolicy)
+	for k, v := range val {
+		switch k {
+		case "failureRate":
+			breaker.FailureRate = conf.ToFloat64(v)
+		case "minRequests":
+			breaker.MinRequests = conf.ToInt(v)
+		case "window":
+			breaker.Window = conf.ToDuration(v)
+		case "openDuration":
+			breaker.OpenDuration = conf.ToDuration(v)
+		case "halfOpenProbes":
+			breaker.HalfOpenProbes = conf.ToInt(v)
+		default:
+			unknown(k)
+		}
+	}
+	return breaker
+}
+
+func getTLS(val map[string]interface{}, unknown func(key string)) *TLSOptions {
+	if len(val) == 0 {
+		return nil
+	}
+	opts := new(TLSOptions)
+	for k, v := range val {
+		switch k {
+		case "caFile":
+			opts.CAFile = conf.ToString(v)
+		case "certFile":
+			opts.CertFile = conf.ToString(v)
+		case "keyFile":
+			opts.KeyFile = conf.ToString(v)
+		case "serverName":
+			opts.ServerName = conf.ToString(v)
+		case "insecureSkipVerify":
+			opts.InsecureSkipVerify = conf.ToBool(v)
+		default:
+			unknown(k)
+		}
 	}
+	return opts
 }
 
-func getSafe(val map[string]interface{}) *mgo.Safe {
+func getSafe(val map[string]interface{}, unknown func(key string)) *mgo.Safe {
 	safe := &mgo.Safe{
 		WMode: Safe_majority,
 	}
@@ -111,6 +360,8 @@ func getSafe(val map[string]interface{}) *mgo.Safe {
 			safe.FSync = conf.ToBool(v)
 		case "J", "j":
 			safe.J = conf.ToBool(v)
+		default:
+			unknown(k)
 		}
 	}
 	return safe
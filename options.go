@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"time"
+)
+
+// callOptions 单次调用的选项, 覆盖Config中的Mode及Safe
+type callOptions struct {
+	mode      *mgo.Mode
+	safe      *mgo.Safe
+	safeSet   bool // WithSafe(nil)表示不等待确认
+	hint      []string
+	maxTime   time.Duration
+	collation *mgo.Collation
+	comment   string
+	batch     int
+	explain   *Plan
+}
+
+// CallOption 单次调用的选项, 通过WithOptions附加到ctx后传给XxxCtx方法
+type CallOption func(o *callOptions)
+
+// WithMode 读偏好, 如将统计查询发往secondary
+func WithMode(mode mgo.Mode) CallOption {
+	return func(o *callOptions) {
+		o.mode = &mode
+	}
+}
+
+// WithSafe 写确认, nil表示不等待确认
+func WithSafe(safe *mgo.Safe) CallOption {
+	return func(o *callOptions) {
+		o.safe, o.safeSet = safe, true
+	}
+}
+
+// WithHint 强制使用索引, key格式与EnsureIndexKey相同, 如"-ctime"
+func WithHint(key ...string) CallOption {
+	return func(o *callOptions) {
+		o.hint = key
+	}
+}
+
+// WithMaxTime 服务端maxTimeMS, 与ctx的deadline同时存在时取较小值
+func WithMaxTime(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.maxTime = d
+	}
+}
+
+func WithCollation(collation *mgo.Collation) CallOption {
+	return func(o *callOptions) {
+		o.collation = collation
+	}
+}
+
+// WithComment 附加到查询的注释, 可在profiler及currentOp中查看
+func WithComment(comment string) CallOption {
+	return func(o *callOptions) {
+		o.comment = comment
+	}
+}
+
+// WithBatchSize 游标批量大小, FindIter等的batch参数大于0时以参数为准
+func WithBatchSize(n int) CallOption {
+	return func(o *callOptions) {
+		o.batch = n
+	}
+}
+
+/*
+WithExplain 不执行查询, 改为explain并将胜出计划的摘要写入plan, 作用于Find*, Select*, Aggregate*:
+1. ret不填充, FindOne等返回false, FindPage的tot为0, Iter直接结束
+2. 内存实现按EnsureIndex的索引模拟计划, 规则见README
+*/
+func WithExplain(plan *Plan) CallOption {
+	return func(o *callOptions) {
+		o.explain = plan
+	}
+}
+
+type callOptionsKey struct{}
+
+/*
+WithOptions 返回附加了调用选项的ctx, 作用于使用该ctx的Find*, Select*, Count, Aggregate, FindAndModify及写操作:
+1. Mode, Safe作用于拷贝的会话
+2. Hint, MaxTime, Collation, Comment, BatchSize作用于查询(聚合只支持MaxTime, Collation, BatchSize)
+ctx中已有选项时在其基础上追加. 内存实现忽略WithExplain以外的选项
+*/
+func WithOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := new(callOptions)
+	if prev := callOptionsFrom(ctx); prev != nil {
+		*o = *prev
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+func callOptionsFrom(ctx context.Context) *callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(*callOptions)
+	return o
+}
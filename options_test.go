@@ -0,0 +1,34 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithOptions(t *testing.T) {
+	ctx := WithOptions(context.Background(), WithMode(mgo.Secondary), WithHint("-ctime"), WithMaxTime(time.Second))
+	ctx2 := WithOptions(ctx, WithSafe(nil), WithComment("report"), WithMaxTime(2*time.Second))
+
+	o := callOptionsFrom(ctx)
+	if *o.mode != mgo.Secondary || !reflect.DeepEqual(o.hint, []string{"-ctime"}) || o.maxTime != time.Second || o.safeSet || o.comment != "" {
+		t.Errorf("WithOptions: %+v", o)
+	}
+	o2 := callOptionsFrom(ctx2)
+	if *o2.mode != mgo.Secondary || !o2.safeSet || o2.safe != nil || o2.comment != "report" || o2.maxTime != 2*time.Second {
+		t.Errorf("WithOptions on existing options: %+v", o2)
+	}
+	if callOptionsFrom(context.Background()) != nil {
+		t.Error("callOptionsFrom without options: expected nil")
+	}
+
+	// 内存实现忽略调用选项
+	m := newMemoryFixture(t)
+	var ret []memUser
+	ctx = WithOptions(context.Background(), WithCollation(&mgo.Collation{Locale: "en"}), WithBatchSize(1))
+	if err := m.SelectAllCtx(ctx, "user", &ret, nil, nil); err != nil || len(ret) != 4 {
+		t.Errorf("SelectAllCtx with options: %v %v", err, ret)
+	}
+}
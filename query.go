@@ -0,0 +1,169 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"time"
+)
+
+/*
+Query 查询条件构造器, 可直接作为Mongo各方法的query参数:
+mongo.Q().Eq("type", t).Gte("ctime", begin).Lte("ctime", end).Or(mongo.Q().Regex("username", op, ""), mongo.Q().Regex("operator", op, ""))
+值为空(nil, 空字符串, 空slice/map, 零值time.Time)的条件自动忽略, 数值0及bool不视为空. 需要保留空值时使用Where
+*/
+type Query struct {
+	fields bson.M
+	ors    [][]bson.M
+	ands   []bson.M
+}
+
+func Q() *Query {
+	return &Query{fields: bson.M{}}
+}
+
+// Where 无条件设置字段, 不检查空值
+func (q *Query) Where(key string, value interface{}) *Query {
+	q.fields[key] = value
+	return q
+}
+
+func (q *Query) Eq(key string, value interface{}) *Query {
+	return q.op(key, "$eq", value)
+}
+
+func (q *Query) Ne(key string, value interface{}) *Query {
+	return q.op(key, "$ne", value)
+}
+
+func (q *Query) Gt(key string, value interface{}) *Query {
+	return q.op(key, "$gt", value)
+}
+
+func (q *Query) Gte(key string, value interface{}) *Query {
+	return q.op(key, "$gte", value)
+}
+
+func (q *Query) Lt(key string, value interface{}) *Query {
+	return q.op(key, "$lt", value)
+}
+
+func (q *Query) Lte(key string, value interface{}) *Query {
+	return q.op(key, "$lte", value)
+}
+
+func (q *Query) In(key string, values interface{}) *Query {
+	return q.op(key, "$in", values)
+}
+
+func (q *Query) Nin(key string, values interface{}) *Query {
+	return q.op(key, "$nin", values)
+}
+
+// Regex pattern为空时忽略, options如"i"
+func (q *Query) Regex(key string, pattern string, options string) *Query {
+	if pattern == "" {
+		return q
+	}
+	q.op(key, "$regex", pattern)
+	return q.op(key, "$options", options)
+}
+
+func (q *Query) Exists(key string, exists bool) *Query {
+	return q.op(key, "$exists", exists)
+}
+
+// Or 忽略没有条件的子查询, 多次调用时各组之间为and关系
+func (q *Query) Or(qs ...*Query) *Query {
+	if or := subQueries(qs); len(or) > 0 {
+		q.ors = append(q.ors, or)
+	}
+	return q
+}
+
+// And 忽略没有条件的子查询
+func (q *Query) And(qs ...*Query) *Query {
+	q.ands = append(q.ands, subQueries(qs)...)
+	return q
+}
+
+// Empty 没有任何条件
+func (q *Query) Empty() bool {
+	return len(q.fields) == 0 && len(q.ors) == 0 && len(q.ands) == 0
+}
+
+// M 生成查询文档. 字段只有$eq条件时直接使用值
+func (q *Query) M() bson.M {
+	ret := make(bson.M, len(q.fields)+2)
+	for k, v := range q.fields {
+		if ops, ok := v.(queryOps); ok {
+			if eq, ok := ops["$eq"]; ok && len(ops) == 1 {
+				v = eq
+			} else {
+				v = bson.M(ops)
+			}
+		}
+		ret[k] = v
+	}
+	ands := q.ands
+	switch len(q.ors) {
+	case 0:
+	case 1:
+		ret["$or"] = q.ors[0]
+	default:
+		for _, or := range q.ors {
+			ands = append(ands, bson.M{"$or": or})
+		}
+	}
+	if len(ands) > 0 {
+		ret["$and"] = ands
+	}
+	return ret
+}
+
+// GetBSON 实现bson.Getter, 使*Query可直接传给mgo
+func (q *Query) GetBSON() (interface{}, error) {
+	return q.M(), nil
+}
+
+// queryOps 同一字段的操作符集合, 与Where设置的bson.M区分
+type queryOps bson.M
+
+func (q *Query) op(key string, op string, value interface{}) *Query {
+	if isEmpty(value) {
+		return q
+	}
+	ops, ok := q.fields[key].(queryOps)
+	if !ok {
+		ops = queryOps{}
+		q.fields[key] = ops
+	}
+	ops[op] = value
+	return q
+}
+
+func subQueries(qs []*Query) []bson.M {
+	var ret []bson.M
+	for _, q := range qs {
+		if q != nil && !q.Empty() {
+			ret = append(ret, q.M())
+		}
+	}
+	return ret
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if t, ok := v.(time.Time); ok {
+		return t.IsZero()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	var kw []string
+	q := Q().Eq("type", "login").Eq("operator", "").Where("batch", false).
+		Gte("ctime", int64(100)).Lte("ctime", int64(0)).In("tags", kw).Gt("mtime", time.Time{}).Eq("status", 0).
+		Or(Q().Regex("username", "bob", "i"), Q().Regex("operator", "", "")).
+		Or(Q().Eq("a", 1), Q().Eq("b", 2))
+	want := bson.M{
+		"type":   "login",
+		"batch":  false,
+		"ctime":  bson.M{"$gte": int64(100), "$lte": int64(0)},
+		"status": 0,
+		"$and": []bson.M{
+			{"$or": []bson.M{{"username": bson.M{"$regex": "bob", "$options": "i"}}}},
+			{"$or": []bson.M{{"a": 1}, {"b": 2}}},
+		},
+	}
+	if got := q.M(); !reflect.DeepEqual(got, want) {
+		t.Errorf("M: %v", got)
+	}
+	if !Q().Eq("a", nil).Or(Q()).And(Q().Ne("b", "")).Empty() {
+		t.Error("Empty: expected empty query")
+	}
+}
+
+func TestQueryFind(t *testing.T) {
+	m := newMemoryFixture(t)
+	var us []memUser
+	q := Q().Gte("age", 25).Lt("age", 35).Or(Q().Eq("tags", "b"), Q().Gt("ctime", int64(350)))
+	if err := m.FindAll("user", &us, q, "_id"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids(us), []int{1, 2, 4}) {
+		t.Errorf("FindAll: %v", ids(us))
+	}
+}
@@ -0,0 +1,247 @@
+package mongo
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Registry 并发安全的实例注册表:
+1. 修改串行执行, 每次修改生成新的只读map, 读取(Get, List, GetDefault)无锁
+2. Default可原子替换
+*/
+type Registry struct {
+	mu      sync.Mutex
+	clients atomic.Value // map[string]Mongo, 只读
+	def     atomic.Value // mongoHolder
+	publish func(clients map[string]Mongo, def Mongo)
+}
+
+// mongoHolder atomic.Value不能存储nil
+type mongoHolder struct {
+	Mongo
+}
+
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.clients.Store(map[string]Mongo{})
+	r.def.Store(mongoHolder{})
+	return r
+}
+
+// defaultRegistry 包级函数及Setup使用的注册表, 变化时同步更新Clients及Default快照
+var defaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.publish = func(clients map[string]Mongo, def Mongo) {
+		Clients, Default = clients, def
+	}
+	return r
+}
+
+func (r *Registry) snapshot() map[string]Mongo {
+	return r.clients.Load().(map[string]Mongo)
+}
+
+func (r *Registry) Get(key string) Mongo {
+	return r.snapshot()[key]
+}
+
+// List 返回排序后的所有key
+func (r *Registry) List() []string {
+	clients := r.snapshot()
+	keys := make([]string, 0, len(clients))
+	for k := range clients {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Registry) GetDefault() Mongo {
+	return r.def.Load().(mongoHolder).Mongo
+}
+
+func (r *Registry) SetDefault(m Mongo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(r.snapshot(), m)
+}
+
+// Register 以一个或多个key注册实例, 任一key已存在时返回错误且不做修改
+func (r *Registry) Register(key string, m Mongo, def bool) error {
+	return r.add(strings.Split(key, ","), m, def)
+}
+
+// Unregister 移除key并返回对应实例, 不关闭实例. key是Default时Default不变
+func (r *Registry) Unregister(key string) Mongo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.snapshot()
+	m, ok := old[key]
+	if !ok {
+		return nil
+	}
+	clients := make(map[string]Mongo, len(old))
+	for k, v := range old {
+		if k != key {
+			clients[k] = v
+		}
+	}
+	r.update(clients, r.GetDefault())
+	return m
+}
+
+/*
+Replace 原子替换key对应的实例, key不存在时直接注册:
+1. 原实例是Default时一并替换Default, 其它key引用的原实例也一并替换
+2. 替换后等待原实例进行中的调用结束再关闭, 返回Close的错误
+*/
+func (r *Registry) Replace(key string, m Mongo) error {
+	if prev := r.replace(key, m); prev != nil && prev != m {
+		return prev.Close()
+	}
+	return nil
+}
+
+// replace 替换后返回原实例, 不关闭
+func (r *Registry) replace(key string, m Mongo) Mongo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.snapshot()
+	prev := old[key]
+	clients := make(map[string]Mongo, len(old)+1)
+	for k, v := range old {
+		if v == prev && prev != nil {
+			v = m
+		}
+		clients[k] = v
+	}
+	clients[key] = m
+	def := r.GetDefault()
+	if def == prev && prev != nil {
+		def = m
+	}
+	r.update(clients, def)
+	return prev
+}
+
+// clearDefault Default是m时清空Default
+func (r *Registry) clearDefault(m Mongo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.GetDefault() == m {
+		r.update(r.snapshot(), nil)
+	}
+}
+
+// remove 移除引用m的所有key, m是Default时清空Default. 不关闭
+func (r *Registry) remove(m Mongo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.snapshot()
+	clients := make(map[string]Mongo, len(old))
+	for k, v := range old {
+		if v != m {
+			clients[k] = v
+		}
+	}
+	def := r.GetDefault()
+	if def == m {
+		def = nil
+	}
+	r.update(clients, def)
+}
+
+func (r *Registry) add(keys []string, m Mongo, def bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.snapshot()
+	if err := r.check(old, keys); err != nil {
+		return err
+	}
+	clients := make(map[string]Mongo, len(old)+len(keys))
+	for k, v := range old {
+		clients[k] = v
+	}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); len(k) > 0 {
+			clients[k] = m
+		}
+	}
+	d := r.GetDefault()
+	if def {
+		d = m
+	}
+	r.update(clients, d)
+	return nil
+}
+
+func (r *Registry) check(clients map[string]Mongo, keys []string) error {
+	for _, k := range keys {
+		if _, ok := clients[strings.TrimSpace(k)]; ok {
+			return errors.New("duplicate mongo key " + k)
+		}
+	}
+	return nil
+}
+
+// reset 清空注册表, 返回原有的实例及其key
+func (r *Registry) reset() map[Mongo][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make(map[Mongo][]string)
+	for k, m := range r.snapshot() {
+		names[m] = append(names[m], k)
+	}
+	if def := r.GetDefault(); def != nil {
+		if _, ok := names[def]; !ok {
+			names[def] = nil
+		}
+	}
+	r.update(map[string]Mongo{}, nil)
+	return names
+}
+
+func (r *Registry) update(clients map[string]Mongo, def Mongo) {
+	r.clients.Store(clients)
+	r.def.Store(mongoHolder{def})
+	if r.publish != nil {
+		r.publish(clients, def)
+	}
+}
+
+// RegisterClient 注册到默认注册表, 见Registry.Register. 包级函数带Client后缀以免与Replace(doc)等冲突
+func RegisterClient(key string, m Mongo, def bool) error {
+	return defaultRegistry.Register(key, m, def)
+}
+
+func UnregisterClient(key string) Mongo {
+	return defaultRegistry.Unregister(key)
+}
+
+func ReplaceClient(key string, m Mongo) error {
+	return defaultRegistry.Replace(key, m)
+}
+
+func ListClients() []string {
+	return defaultRegistry.List()
+}
+
+func GetDefault() Mongo {
+	return defaultRegistry.GetDefault()
+}
+
+func SetDefault(m Mongo) {
+	defaultRegistry.SetDefault(m)
+}
@@ -0,0 +1,86 @@
+package mongo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	a := newMemoryMongo(&Config{Database: "test"})
+	if err := r.Register("reg_a, reg_b", a, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("reg_c,reg_b", newMemoryMongo(&Config{}), false); err == nil {
+		t.Error("Register duplicate key: expected error")
+	}
+	if keys := r.List(); !reflect.DeepEqual(keys, []string{"reg_a", "reg_b"}) {
+		t.Errorf("List: %v", keys)
+	}
+	if r.GetDefault() != a || r.Get("reg_b") != a {
+		t.Fatal("Register: default or key not set")
+	}
+
+	// 并发读取期间替换, 旧实例在进行中的调用结束后关闭
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if r.Get("reg_a") == nil || r.GetDefault() == nil {
+					t.Error("Get during Replace: nil")
+					return
+				}
+			}
+		}()
+	}
+	a.life.acquire()
+	b := newMemoryMongo(&Config{Database: "test"})
+	done := make(chan error)
+	go func() {
+		done <- r.Replace("reg_a", b)
+	}()
+	for i := 0; r.Get("reg_a") != b; i++ {
+		if i == 100 {
+			t.Fatal("Replace: not swapped before drain")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r.Get("reg_b") != b || r.GetDefault() != b {
+		t.Error("Replace: other key or default not swapped")
+	}
+	select {
+	case <-done:
+		t.Fatal("Replace returned before in-flight call finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+	a.life.release()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	wg.Wait()
+	if _, err := a.Count("user"); err != ErrClosed {
+		t.Errorf("old client after Replace: %v", err)
+	}
+
+	if m := r.Unregister("reg_b"); m != b {
+		t.Errorf("Unregister: %v", m)
+	}
+	if r.Unregister("reg_b") != nil || !reflect.DeepEqual(r.List(), []string{"reg_a"}) {
+		t.Errorf("Unregister: %v", r.List())
+	}
+	r.SetDefault(nil)
+	if r.GetDefault() != nil {
+		t.Error("SetDefault(nil)")
+	}
+}
@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/obase/conf"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 热加载中key的变化类型
+const (
+	ReloadAdded   = "added"
+	ReloadChanged = "changed"
+	ReloadRemoved = "removed"
+)
+
+// ReloadEvent 热加载中单个实例的变化. Err非nil表示处理失败, 原实例保持不变
+type ReloadEvent struct {
+	Key    string // conf.yml中的key, 多值用逗号分隔
+	Action string // ReloadAdded, ReloadChanged或ReloadRemoved
+	Err    error
+}
+
+// ReloadHook 热加载事件回调, 每个变化的实例回调一次
+var ReloadHook func(ev *ReloadEvent)
+
+// confEntry 从conf.yml加载的实例配置
+type confEntry struct {
+	option *Config
+	defalt bool
+}
+
+// confClients 记录从conf.yml加载的实例, 热加载只比较这些实例, 不影响代码中Setup的实例
+type confClients struct {
+	sync.Mutex
+	entries map[string]*confEntry
+}
+
+var loaded = &confClients{entries: make(map[string]*confEntry)}
+
+func (c *confClients) set(key string, option *Config, defalt bool) {
+	c.Lock()
+	c.entries[clientLabel(strings.Split(key, ","))] = &confEntry{option: option, defalt: defalt}
+	c.Unlock()
+}
+
+/*
+Reload 重新读取conf.yml(查找顺序与obase/conf相同: 环境变量CONF_YAML, 程序目录, 工作目录)并热加载mongo配置:
+1. 配置变化的实例重新创建并原子替换, 旧实例在进行中的调用结束后关闭
+2. 删除的实例从注册表移除并关闭
+3. 新增的实例注册
+每个变化通过ReloadHook回调. 部分实例失败时其余变化照常生效, 返回汇总的错误
+*/
+func Reload() error {
+	path := confPath()
+	if path == "" {
+		return errors.New("mongo reload: conf.yml not found")
+	}
+	return ReloadFile(path)
+}
+
+// ReloadFile 从指定文件热加载mongo配置, 见Reload
+func ReloadFile(path string) error {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[interface{}]interface{})
+	if err = yaml.Unmarshal(bs, &values); err != nil {
+		return err
+	}
+	configs, ok := values[CKEY].([]interface{})
+	if !ok && values[CKEY] != nil {
+		return errors.New("mongo reload: invalid mongo section in " + path)
+	}
+	return reload(configs)
+}
+
+func reload(configs []interface{}) error {
+	entries := make(map[string]*confEntry)
+	invalid := make(map[string]bool) // 新配置有误的实例保持原状, 不视为删除
+	var errs []string
+	for i, config := range configs {
+		key, option, defalt, err := getConfig(config)
+		key = clientLabel(strings.Split(key, ","))
+		if err != nil {
+			invalid[key] = true
+			errs = append(errs, fmt.Sprintf("%v[%v]%v: %v", CKEY, i, key, err))
+			continue
+		}
+		if _, dup := entries[key]; dup {
+			errs = append(errs, "duplicate mongo key "+key)
+			continue
+		}
+		entries[key] = &confEntry{option: option, defalt: defalt}
+	}
+
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	var events []*ReloadEvent
+	for _, key := range entryKeys(loaded.entries) {
+		if _, ok := entries[key]; !ok && !invalid[key] {
+			if m := Get(strings.Split(key, ",")[0]); m != nil {
+				defaultRegistry.remove(m)
+				go m.Close()
+			}
+			delete(loaded.entries, key)
+			events = append(events, &ReloadEvent{Key: key, Action: ReloadRemoved})
+		}
+	}
+	for _, key := range entryKeys(entries) {
+		entry, old := entries[key], loaded.entries[key]
+		if old != nil && reflect.DeepEqual(old, entry) {
+			continue
+		}
+		ev := &ReloadEvent{Key: key, Action: ReloadAdded}
+		if old != nil {
+			ev.Action = ReloadChanged
+		}
+		ev.Err = reloadClient(key, entry, old)
+		if ev.Err == nil {
+			loaded.entries[key] = entry
+		} else {
+			errs = append(errs, key+": "+ev.Err.Error())
+		}
+		events = append(events, ev)
+	}
+
+	if ReloadHook != nil {
+		for _, ev := range events {
+			ReloadHook(ev)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("mongo reload: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// reloadClient old为nil时新增实例, 否则替换原实例
+func reloadClient(key string, entry *confEntry, old *confEntry) error {
+	changed := old != nil
+	keys := strings.Split(key, ",")
+	if !changed {
+		if err := defaultRegistry.check(defaultRegistry.snapshot(), keys); err != nil {
+			return err
+		}
+	}
+	m, err := newClient(keys, entry.option, nil)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		if err = defaultRegistry.add(keys, m, entry.defalt); err != nil {
+			m.Close()
+		}
+		return err
+	}
+	// 原实例的所有key及Default一并替换, 旧实例在进行中的调用结束后关闭
+	if prev := defaultRegistry.replace(keys[0], m); prev != nil && prev != m {
+		go prev.Close()
+	}
+	if entry.defalt {
+		SetDefault(m)
+	} else if old.defalt {
+		// 取消default时Default不再指向本实例
+		defaultRegistry.clearDefault(m)
+	}
+	return nil
+}
+
+func entryKeys(entries map[string]*confEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// confPath 与obase/conf的查找顺序相同, 找不到返回空
+func confPath() string {
+	if path := os.Getenv(conf.CONF_YAML_ENV); path != "" {
+		return path
+	}
+	loc, _ := exec.LookPath(os.Args[0])
+	for _, dir := range []string{filepath.Dir(loc), ""} {
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		path := filepath.Join(dir, conf.CONF_YAML_FILE)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
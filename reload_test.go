@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	saved, savedLoaded := defaultRegistry, loaded
+	defer func() {
+		defaultRegistry, loaded, ReloadHook = saved, savedLoaded, nil
+		Default, Clients = saved.GetDefault(), saved.snapshot()
+	}()
+	defaultRegistry, loaded = newDefaultRegistry(), &confClients{entries: make(map[string]*confEntry)}
+	if err := Setup("reload_manual", &Config{Database: "test", Memory: true}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "mongo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conf.yml")
+
+	var events []ReloadEvent
+	ReloadHook = func(ev *ReloadEvent) {
+		events = append(events, *ev)
+	}
+	reload := func(yml string) error {
+		events = nil
+		if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return ReloadFile(path)
+	}
+
+	err = reload(`
+mongo:
+  - {key: "reload_a, reload_b", database: test, memory: true, default: true}
+  - {key: reload_c, database: test, memory: true}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events, []ReloadEvent{{"reload_a,reload_b", ReloadAdded, nil}, {"reload_c", ReloadAdded, nil}}) {
+		t.Errorf("added: %+v", events)
+	}
+	a, c := Get("reload_a"), Get("reload_c")
+	if a == nil || Get("reload_b") != a || GetDefault() != a || c == nil {
+		t.Fatalf("added: %v", ListClients())
+	}
+
+	// 未变化的实例不重建, 新配置与代码中Setup的key冲突时报错且不影响其余变化
+	a.(*memMongo).life.acquire()
+	err = reload(`
+mongo:
+  - {key: "reload_a,reload_b", database: test, memory: true, strict: true, default: true}
+  - {key: reload_manual, database: test, memory: true}
+`)
+	if err == nil {
+		t.Error("reload with conflicting key: expected error")
+	}
+	if len(events) != 3 || events[0] != (ReloadEvent{"reload_c", ReloadRemoved, nil}) ||
+		events[1] != (ReloadEvent{"reload_a,reload_b", ReloadChanged, nil}) || events[2].Action != ReloadAdded || events[2].Err == nil {
+		t.Errorf("changed: %+v", events)
+	}
+	na := Get("reload_a")
+	if na == a || Get("reload_b") != na || GetDefault() != na || !na.(*memMongo).Strict {
+		t.Error("changed: not replaced")
+	}
+	if Get("reload_c") != nil || !reflect.DeepEqual(ListClients(), []string{"reload_a", "reload_b", "reload_manual"}) {
+		t.Errorf("removed: %v", ListClients())
+	}
+	for i := 0; c.Ping(context.Background()) != ErrClosed; i++ {
+		if i == 100 {
+			t.Fatal("removed client not closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 旧实例在进行中的调用结束后才关闭
+	drained := a.(*memMongo).life.shutdown()
+	select {
+	case <-drained:
+		t.Error("replaced client closed before drain")
+	default:
+	}
+	a.(*memMongo).life.release()
+	<-drained
+
+	if err = reload("mongo:\n  - {key: \"reload_a,reload_b\", database: test, memory: true, strict: true, default: true}\n"); err != nil || len(events) != 0 {
+		t.Errorf("unchanged: %v %+v", err, events)
+	}
+
+	// 取消default后Default不再指向重建的实例
+	if err = reload("mongo:\n  - {key: \"reload_a,reload_b\", database: test, memory: true}\n"); err != nil ||
+		len(events) != 1 || events[0] != (ReloadEvent{"reload_a,reload_b", ReloadChanged, nil}) {
+		t.Errorf("default removed: %v %+v", err, events)
+	}
+	if Get("reload_a") == na || GetDefault() != nil {
+		t.Errorf("default removed: Default=%v", GetDefault())
+	}
+}
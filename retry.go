@@ -0,0 +1,205 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// 可重试的错误类别
+const (
+	RetryNetwork   = "network"   // EOF, 连接断开, 网络超时, 无可用服务器
+	RetryNotMaster = "notMaster" // 主节点切换: not master, node is recovering等
+)
+
+// RetryPolicy 重试策略. 读操作自动重试; 写操作仅在幂等或ctx经WithRetry标记时重试
+type RetryPolicy struct {
+	Attempts   int           // 最大尝试次数(含首次), <=1不重试
+	Backoff    time.Duration // 首次重试前等待, 之后每次翻倍. 默认100ms
+	MaxBackoff time.Duration // 等待上限, 默认2s
+	Jitter     float64       // 等待时间的随机浮动比例, 0~1
+	Errors     []string      // 重试的错误类别, 默认RetryNetwork, RetryNotMaster
+}
+
+type retryKey struct{}
+
+// WithRetry 标记ctx中的写操作允许重试, 调用方需自行保证重复执行是安全的
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, true)
+}
+
+func retryEnabled(ctx context.Context) bool {
+	ok, _ := ctx.Value(retryKey{}).(bool)
+	return ok
+}
+
+// retryInterceptor 按策略重试, 每次重试前调用refresh刷新底层会话
+func retryInterceptor(p *RetryPolicy, refresh func()) Interceptor {
+	backoff, maxBackoff, classes := p.Backoff, p.MaxBackoff, p.Errors
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 2 * time.Second
+	}
+	if len(classes) == 0 {
+		classes = []string{RetryNetwork, RetryNotMaster}
+	}
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		err := next(ctx, op)
+		if err == nil || !(retryEnabled(ctx) || retryable(op)) {
+			return err
+		}
+		wait := backoff
+		for i := 1; i < p.Attempts && err != nil && retryClass(err, classes); i++ {
+			d := wait
+			if p.Jitter > 0 {
+				d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(wait))
+			}
+			if wait *= 2; wait > maxBackoff {
+				wait = maxBackoff
+			}
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+			if refresh != nil {
+				refresh()
+			}
+			err = next(ctx, op)
+		}
+		return err
+	}
+}
+
+// retryable 读操作及幂等写操作可自动重试
+func retryable(op *Operation) bool {
+	switch op.Name {
+	case "Count", "CountWhere", "EstimatedCount", "Exists", "Indexes",
+		"FindOne", "FindAll", "FindRange", "FindPage", "FindDistinct", "FindId", "FindIter", "FindKeyset",
+		"SelectOne", "SelectAll", "SelectRange", "SelectPage", "SelectDistinct", "SelectId", "SelectIter", "SelectKeyset",
+		"RemoveId", "EnsureIndex", "EnsureIndexKey",
+		"CollectionStats", "ListCollections", "DatabaseStats", "ListDatabases", "DropCollection", "DropDatabase":
+		return true
+	case "Aggregate", "AggregateOne":
+		return !writesPipeline(op.Query)
+	case "UpdateId", "UpsertOne", "UpsertId":
+		return idempotentUpdate(op.Update)
+	}
+	return false
+}
+
+// writesPipeline 包含$out或$merge的管道会写入集合
+func writesPipeline(pipeline interface{}) bool {
+	if pipeline == nil {
+		return false
+	}
+	data, err := bson.Marshal(bson.M{"p": pipeline})
+	if err != nil {
+		return true
+	}
+	var doc struct {
+		P []bson.M
+	}
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return true
+	}
+	for _, stage := range doc.P {
+		if _, ok := stage["$out"]; ok {
+			return true
+		}
+		if _, ok := stage["$merge"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotentUpdate 整体替换或只包含重复执行结果不变的操作符
+func idempotentUpdate(update interface{}) bool {
+	doc, err := toDoc(update)
+	if err != nil || len(doc) == 0 {
+		return false
+	}
+	for k := range doc {
+		switch k {
+		case "$set", "$unset", "$setOnInsert", "$min", "$max", "$addToSet", "$pull", "$pullAll":
+		default:
+			if strings.HasPrefix(k, "$") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func retryClass(err error, classes []string) bool {
+	for _, c := range classes {
+		switch c {
+		case RetryNetwork:
+			if isNetworkError(err) {
+				return true
+			}
+		case RetryNotMaster:
+			if isNotMasterError(err) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNetworkError(err error) bool {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	msg := err.Error()
+	for _, s := range []string{"no reachable servers", "Closed explicitly", "connection reset", "broken pipe", "i/o timeout", "EOF"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var notMasterCodes = map[int]bool{
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+	13436: true, // NotMasterOrSecondary
+}
+
+func isNotMasterError(err error) bool {
+	var code int
+	switch e := err.(type) {
+	case *mgo.QueryError:
+		code = e.Code
+	case *mgo.LastError:
+		code = e.Code
+	case *mgo.BulkError:
+		for _, c := range e.Cases() {
+			if isNotMasterError(c.Err) {
+				return true
+			}
+		}
+	}
+	if notMasterCodes[code] {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "not master") || strings.Contains(msg, "node is recovering")
+}
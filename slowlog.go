@@ -0,0 +1,122 @@
+package mongo
+
+import (
+	"context"
+	"github.com/globalsign/mgo/bson"
+	"log"
+	"strings"
+	"time"
+)
+
+// SlowQuery 慢查询记录, Query已按RedactFields隐藏敏感值
+type SlowQuery struct {
+	Client     string
+	Database   string
+	Collection string
+	Operation  string
+	Query      interface{}
+	Sort       []string
+	Skip       uint32
+	Limit      uint32
+	Duration   time.Duration
+	Matched    int
+	Err        error
+}
+
+// SlowQueryLogger 慢查询日志接口, 默认使用标准库log输出
+type SlowQueryLogger interface {
+	LogSlowQuery(sq *SlowQuery)
+}
+
+type stdSlowQueryLogger struct{}
+
+func (stdSlowQueryLogger) LogSlowQuery(sq *SlowQuery) {
+	query, err := bson.MarshalJSON(sq.Query)
+	if err != nil {
+		query = []byte(err.Error())
+	}
+	log.Printf("mongo slow query: client=%s db=%s collection=%s op=%s query=%s sort=%v skip=%d limit=%d duration=%v matched=%d err=%v",
+		sq.Client, sq.Database, sq.Collection, sq.Operation, query, sq.Sort, sq.Skip, sq.Limit, sq.Duration, sq.Matched, sq.Err)
+}
+
+// slowQueryInterceptor 记录耗时超过opt.SlowQueryThreshold的操作
+func slowQueryInterceptor(client string, opt *Config) Interceptor {
+	logger := opt.SlowQueryLogger
+	if logger == nil {
+		logger = stdSlowQueryLogger{}
+	}
+	threshold, redact := opt.SlowQueryThreshold, opt.RedactFields
+	return func(ctx context.Context, op *Operation, next Invoker) error {
+		start := time.Now()
+		err := next(ctx, op)
+		if d := time.Since(start); d >= threshold {
+			logger.LogSlowQuery(&SlowQuery{
+				Client:     client,
+				Database:   op.Database,
+				Collection: op.Collection,
+				Operation:  op.Name,
+				Query:      redactQuery(op.Query, redact),
+				Sort:       op.Options.Sort,
+				Skip:       op.Options.Skip,
+				Limit:      op.Options.Limit,
+				Duration:   d,
+				Matched:    op.Matched,
+				Err:        err,
+			})
+		}
+		return err
+	}
+}
+
+const redacted = "***"
+
+// redactQuery 将字段名(或点路径的最后一段)在fields中的值替换为***, 递归处理$or等嵌套条件
+func redactQuery(query interface{}, fields []string) interface{} {
+	if query == nil || len(fields) == 0 {
+		return query
+	}
+	var v interface{}
+	switch query.(type) {
+	case []interface{}, []bson.M, bson.D:
+		v = cloneValue(query)
+	default:
+		doc, err := toDoc(query)
+		if err != nil {
+			return query // 无法转换为文档, 例如id
+		}
+		v = doc
+	}
+	return redactValue(v, fields)
+}
+
+func redactValue(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case bson.M:
+		for k, e := range v {
+			if isRedacted(k, fields) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(e, fields)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redactValue(e, fields)
+		}
+		return v
+	}
+	return v
+}
+
+func isRedacted(key string, fields []string) bool {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	for _, f := range fields {
+		if key == f {
+			return true
+		}
+	}
+	return false
+}
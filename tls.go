@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"time"
+)
+
+// TLSOptions TLS连接选项, Config.TLS非nil时启用
+type TLSOptions struct {
+	CAFile             string // CA证书(PEM), 默认使用系统根证书
+	CertFile           string // 客户端证书(PEM), 与KeyFile同时配置
+	KeyFile            string // 客户端私钥(PEM)
+	ServerName         string // 校验证书的服务器名称, 默认为连接的主机名
+	InsecureSkipVerify bool   // 不校验服务器证书, 仅用于开发环境
+}
+
+func (o *TLSOptions) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in tls caFile " + o.CAFile)
+		}
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+/*
+dialTCP 建立到addr的连接, 设置keepalive. tlsConfig非nil时在TCP连接上完成TLS握手:
+1. 未指定ServerName时使用addr的主机名
+2. 握手超时为ConnectTimeout
+*/
+func dialTCP(opt *Config, tlsConfig *tls.Config, addr string, tcpAddr *net.TCPAddr) (net.Conn, error) {
+	tcp, err := net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Keepalive > 0 {
+		tcp.SetKeepAlive(true)
+		tcp.SetKeepAlivePeriod(opt.Keepalive)
+	}
+	if tlsConfig == nil {
+		return tcp, nil
+	}
+
+	cfg := tlsConfig
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		if cfg.ServerName, _, err = net.SplitHostPort(addr); err != nil {
+			cfg.ServerName = addr
+		}
+	}
+	conn := tls.Client(tcp, cfg)
+	if opt.ConnectTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(opt.ConnectTimeout))
+	}
+	if err = conn.Handshake(); err != nil {
+		tcp.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
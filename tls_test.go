@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert 生成由parent签发的证书及私钥, 写入dir/name.pem及dir/name.key. parent为nil时自签名
+func writeCert(t *testing.T, dir string, name string, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.Subject = pkix.Name{CommonName: name}
+	tmpl.NotBefore, tmpl.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestDialTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mongo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil, nil)
+	writeCert(t, dir, "server", &x509.Certificate{DNSNames: []string{"mongo.test"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, ca, caKey)
+	writeCert(t, dir, "client", &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, ca, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4)
+				if n, err := conn.Read(buf); err == nil {
+					conn.Write(buf[:n])
+				}
+			}()
+		}
+	}()
+
+	addr := ln.Addr().String()
+	tcpAddr := ln.Addr().(*net.TCPAddr)
+	dial := func(opts *TLSOptions) error {
+		cfg, err := opts.config()
+		if err != nil {
+			return err
+		}
+		conn, err := dialTCP(&Config{Keepalive: time.Minute, ConnectTimeout: time.Second}, cfg, addr, tcpAddr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err = conn.Write([]byte("ping")); err != nil {
+			return err
+		}
+		buf := make([]byte, 4)
+		if _, err = conn.Read(buf); err != nil {
+			return err
+		}
+		if string(buf) != "ping" {
+			t.Errorf("echo: %q", buf)
+		}
+		return nil
+	}
+
+	client := &TLSOptions{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		ServerName: "mongo.test",
+	}
+	if err = dial(client); err != nil {
+		t.Fatalf("dial with ca, client cert and server name: %v", err)
+	}
+
+	// 未指定ServerName时使用连接的主机名127.0.0.1, 与证书不符
+	noName := *client
+	noName.ServerName = ""
+	if err = dial(&noName); err == nil {
+		t.Error("dial without server name override: expected error")
+	}
+	noCA := *client
+	noCA.CAFile = ""
+	if err = dial(&noCA); err == nil {
+		t.Error("dial without ca: expected error")
+	}
+	insecure := noCA
+	insecure.InsecureSkipVerify = true
+	if err = dial(&insecure); err != nil {
+		t.Errorf("dial with insecure skip verify: %v", err)
+	}
+
+	opt := &Config{Address: []string{addr}, Database: "test", TLS: &TLSOptions{CertFile: filepath.Join(dir, "missing.pem")}}
+	if err = opt.Validate(); err == nil {
+		t.Error("Validate tls without keyFile: expected error")
+	}
+}
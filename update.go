@@ -0,0 +1,131 @@
+package mongo
+
+import (
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"strings"
+)
+
+var ErrUpdateWithoutOperator = errors.New("update document without operator, use mongo.Replace for replacement")
+
+// Update 更新文档构造器, 可直接作为UpdateXxx, FindAndUpdateXxx, UpsertXxx的update参数:
+// mongo.U().Set("name", name).Inc("visits", 1).CurrentDate("mtime")
+type Update struct {
+	ops bson.M
+}
+
+func U() *Update {
+	return &Update{ops: bson.M{}}
+}
+
+func (u *Update) Set(key string, value interface{}) *Update {
+	return u.op("$set", key, value)
+}
+
+func (u *Update) Unset(keys ...string) *Update {
+	for _, k := range keys {
+		u.op("$unset", k, "")
+	}
+	return u
+}
+
+func (u *Update) Inc(key string, value interface{}) *Update {
+	return u.op("$inc", key, value)
+}
+
+func (u *Update) Push(key string, value interface{}) *Update {
+	return u.op("$push", key, value)
+}
+
+func (u *Update) AddToSet(key string, value interface{}) *Update {
+	return u.op("$addToSet", key, value)
+}
+
+// Pull value可以是值或条件
+func (u *Update) Pull(key string, value interface{}) *Update {
+	return u.op("$pull", key, value)
+}
+
+// SetOnInsert 仅在upsert插入时生效
+func (u *Update) SetOnInsert(key string, value interface{}) *Update {
+	return u.op("$setOnInsert", key, value)
+}
+
+// CurrentDate 设置为服务端当前时间
+func (u *Update) CurrentDate(keys ...string) *Update {
+	for _, k := range keys {
+		u.op("$currentDate", k, true)
+	}
+	return u
+}
+
+func (u *Update) Min(key string, value interface{}) *Update {
+	return u.op("$min", key, value)
+}
+
+func (u *Update) Max(key string, value interface{}) *Update {
+	return u.op("$max", key, value)
+}
+
+// M 生成更新文档
+func (u *Update) M() bson.M {
+	return u.ops
+}
+
+// GetBSON 实现bson.Getter, 使*Update可直接传给mgo
+func (u *Update) GetBSON() (interface{}, error) {
+	return u.ops, nil
+}
+
+func (u *Update) op(op string, key string, value interface{}) *Update {
+	m, ok := u.ops[op].(bson.M)
+	if !ok {
+		m = bson.M{}
+		u.ops[op] = m
+	}
+	m[key] = value
+	return u
+}
+
+// replacement 显式声明的整体替换文档
+type replacement struct {
+	doc interface{}
+}
+
+func (r replacement) GetBSON() (interface{}, error) {
+	return r.doc, nil
+}
+
+// Replace 声明doc为整体替换文档, 严格模式下允许不含操作符
+func Replace(doc interface{}) interface{} {
+	return replacement{doc: doc}
+}
+
+// checkUpdate 严格模式下update必须全部为操作符. nil的*Update无法编码, 非严格模式下同样报错
+func (c *Config) checkUpdate(update interface{}) error {
+	if u, ok := update.(*Update); ok {
+		if u == nil || c.Strict && len(u.ops) == 0 {
+			return ErrUpdateWithoutOperator
+		}
+		return nil
+	}
+	if !c.Strict {
+		return nil
+	}
+	if _, ok := update.(replacement); ok {
+		return nil
+	}
+	doc, err := toDoc(update)
+	if err != nil {
+		return err
+	}
+	if len(doc) == 0 {
+		return ErrUpdateWithoutOperator
+	}
+	for k := range doc {
+		if !strings.HasPrefix(k, "$") {
+			return ErrUpdateWithoutOperator
+		}
+	}
+	return nil
+}
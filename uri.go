@@ -0,0 +1,164 @@
+package mongo
+
+import (
+	"errors"
+	"github.com/globalsign/mgo"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+parseURI 解析连接URI: mongodb://[user:pass@]host1[:port1][,host2[:port2],...][/database][?options]
+支持的options: replicaSet, authSource, readPreference, w, wtimeoutMS, journal, maxPoolSize, connectTimeoutMS, 其它返回错误
+*/
+func parseURI(uri string) (*Config, error) {
+	const scheme = "mongodb://"
+	if !strings.HasPrefix(uri, scheme) {
+		return nil, errors.New("invalid uri: scheme must be " + scheme)
+	}
+	rest := uri[len(scheme):]
+
+	c := new(Config)
+	var query string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 {
+		user, pass := rest[:i], ""
+		if j := strings.IndexByte(user, ':'); j >= 0 {
+			user, pass = user[:j], user[j+1:]
+		}
+		var err error
+		if c.Username, err = url.QueryUnescape(user); err != nil {
+			return nil, errors.New("invalid uri username: " + err.Error())
+		}
+		if c.Password, err = url.QueryUnescape(pass); err != nil {
+			return nil, errors.New("invalid uri password: " + err.Error())
+		}
+		rest = rest[i+1:]
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest, c.Database = rest[:i], rest[i+1:]
+	}
+	for _, host := range strings.Split(rest, ",") {
+		if host = strings.TrimSpace(host); host == "" {
+			return nil, errors.New("invalid uri: empty host")
+		}
+		c.Address = append(c.Address, host)
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.New("invalid uri options: " + err.Error())
+	}
+	var safe mgo.Safe
+	var hasSafe bool
+	for k, vs := range values {
+		v := vs[len(vs)-1]
+		switch k {
+		case "replicaSet":
+			c.ReplicaSet = v
+		case "authSource":
+			c.Source = v
+		case "readPreference":
+			switch v {
+			case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+				c.Mode, _ = ParseMode(v)
+			default:
+				return nil, errors.New("invalid uri readPreference: " + v)
+			}
+		case "w":
+			if n, err := strconv.Atoi(v); err == nil {
+				safe.W = n
+			} else {
+				safe.WMode = v
+			}
+			hasSafe = true
+		case "wtimeoutMS":
+			if safe.WTimeout, err = strconv.Atoi(v); err != nil {
+				return nil, errors.New("invalid uri wtimeoutMS: " + v)
+			}
+			hasSafe = true
+		case "journal":
+			if safe.J, err = strconv.ParseBool(v); err != nil {
+				return nil, errors.New("invalid uri journal: " + v)
+			}
+			hasSafe = true
+		case "maxPoolSize":
+			if c.MaxPoolSize, err = strconv.Atoi(v); err != nil {
+				return nil, errors.New("invalid uri maxPoolSize: " + v)
+			}
+		case "connectTimeoutMS":
+			ms, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, errors.New("invalid uri connectTimeoutMS: " + v)
+			}
+			c.ConnectTimeout = time.Duration(ms) * time.Millisecond
+		default:
+			return nil, errors.New("unsupported uri option: " + k)
+		}
+	}
+	if hasSafe {
+		c.Safe = &safe
+	}
+	return c, nil
+}
+
+// applyURI 将URI解析到各字段, 已显式设置(非零值)的字段优先. 解析错误由Validate报告
+func (c *Config) applyURI() {
+	if c.URI == "" || c.uriApplied {
+		return
+	}
+	c.uriApplied = true
+	u, err := parseURI(c.URI)
+	if err != nil {
+		c.problems = append(c.problems, err.Error())
+		return
+	}
+	if len(c.Address) == 0 {
+		c.Address = u.Address
+	}
+	if c.Database == "" {
+		c.Database = u.Database
+	}
+	if c.Username == "" {
+		c.Username = u.Username
+	}
+	if c.Password == "" {
+		c.Password = u.Password
+	}
+	if c.Source == "" {
+		c.Source = u.Source
+	}
+	if c.ReplicaSet == "" {
+		c.ReplicaSet = u.ReplicaSet
+	}
+	if c.Mode == mgo.Eventual {
+		c.Mode = u.Mode
+	}
+	if c.MaxPoolSize == 0 {
+		c.MaxPoolSize = u.MaxPoolSize
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = u.ConnectTimeout
+	}
+	if u.Safe != nil {
+		if c.Safe == nil {
+			c.Safe = u.Safe
+		} else {
+			safe := *c.Safe
+			if safe.W == 0 && safe.WMode == "" {
+				safe.W, safe.WMode = u.Safe.W, u.Safe.WMode
+			}
+			if safe.WTimeout == 0 {
+				safe.WTimeout = u.Safe.WTimeout
+			}
+			if !safe.J {
+				safe.J = u.Safe.J
+			}
+			c.Safe = &safe
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseURI(t *testing.T) {
+	c, err := parseURI("mongodb://root:p%40ss@h1:27017,h2:27018/jx3robot?replicaSet=rs0&authSource=admin&readPreference=secondaryPreferred&w=majority&wtimeoutMS=500&journal=true&maxPoolSize=32&connectTimeoutMS=2000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &Config{
+		Address:        []string{"h1:27017", "h2:27018"},
+		Database:       "jx3robot",
+		Username:       "root",
+		Password:       "p@ss",
+		Source:         "admin",
+		ReplicaSet:     "rs0",
+		Mode:           mgo.SecondaryPreferred,
+		Safe:           &mgo.Safe{WMode: "majority", WTimeout: 500, J: true},
+		MaxPoolSize:    32,
+		ConnectTimeout: 2 * time.Second,
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Errorf("parseURI:\n%+v\n%+v", c, expect)
+	}
+
+	for _, uri := range []string{
+		"127.0.0.1:27017",
+		"mongodb://h1,,h2/test",
+		"mongodb://h1/test?ssl=true",
+		"mongodb://h1/test?readPreference=eventual",
+		"mongodb://h1/test?maxPoolSize=many",
+	} {
+		if _, err = parseURI(uri); err == nil {
+			t.Errorf("parseURI %v: expected error", uri)
+		}
+	}
+}
+
+func TestApplyURI(t *testing.T) {
+	opt := mergeOption(&Config{
+		URI:      "mongodb://root:secret@h1,h2/jx3robot?w=2&maxPoolSize=32&readPreference=nearest",
+		Password: "explicit",
+		Safe:     &mgo.Safe{WMode: "majority"},
+	})
+	if !reflect.DeepEqual(opt.Address, []string{"h1", "h2"}) || opt.Database != "jx3robot" || opt.Username != "root" ||
+		opt.Password != "explicit" || opt.Safe.W != 0 || opt.Safe.WMode != "majority" || opt.MaxPoolSize != 32 || opt.Mode != mgo.Nearest {
+		t.Errorf("applyURI: %+v %+v", opt, opt.Safe)
+	}
+	if err := opt.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	err := Setup("uri_invalid", &Config{URI: "mongodb://h1/test?compressors=zlib", Memory: true}, false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported uri option: compressors") {
+		t.Errorf("Setup with unknown uri option: %v", err)
+	}
+
+	// conf.yml: 显式配置 > uri > 默认值
+	_, opt, _, err = getConfig(map[interface{}]interface{}{
+		"key":  "x",
+		"uri":  "mongodb://h1/jx3robot?maxPoolSize=32&readPreference=secondary&w=1",
+		"mode": "primary",
+	})
+	if err != nil || opt.MaxPoolSize != 32 || opt.Mode != mgo.Primary || opt.Safe.W != 1 || opt.ConnectTimeout != 30*time.Second {
+		t.Errorf("getConfig with uri: %v %+v %+v", err, opt, opt.Safe)
+	}
+}
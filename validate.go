@@ -0,0 +1,195 @@
+package mongo
+
+import (
+	"fmt"
+	"github.com/globalsign/mgo"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// ConfigError 配置检查发现的所有问题
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid mongo config: " + strings.Join(e.Problems, "; ")
+}
+
+/*
+Validate 检查配置, 一次报告所有问题, 通过返回nil:
+1. 缺少address(Memory除外)或database
+2. conf.yml中的未知key及类型错误
+3. 无效的mode或safe
+4. 负数的连接池参数, minPoolSize大于maxPoolSize
+5. 负数的超时, 相互冲突的超时(如safe.WTimeout不小于readTimeout)
+6. 无效的重试及熔断策略
+7. TLS证书文件不存在, certFile与keyFile未同时配置
+Setup时自动调用
+*/
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	problems = append(problems, c.problems...)
+
+	if len(c.Address) == 0 && !c.Memory {
+		addf("missing address")
+	}
+	for _, addr := range c.Address {
+		if strings.TrimSpace(addr) == "" {
+			addf("empty address in %q", strings.Join(c.Address, ","))
+			break
+		}
+	}
+	if c.Database == "" {
+		addf("missing database")
+	}
+	if c.Mode < mgo.Eventual || c.Mode > mgo.Nearest {
+		addf("invalid mode %d", c.Mode)
+	}
+
+	if s := c.Safe; s != nil {
+		if s.W < 0 {
+			addf("negative safe.W %d", s.W)
+		}
+		if s.WTimeout < 0 {
+			addf("negative safe.WTimeout %d", s.WTimeout)
+		}
+		switch s.RMode {
+		case "", Safe_local, Safe_majority, Safe_linearizable, "available", "snapshot":
+		default:
+			addf("invalid safe.RMode %q", s.RMode)
+		}
+		if s.WTimeout > 0 && c.ReadTimeout > 0 && time.Duration(s.WTimeout)*time.Millisecond >= c.ReadTimeout {
+			addf("safe.WTimeout %dms conflicts with readTimeout %v: socket times out before write concern", s.WTimeout, c.ReadTimeout)
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		val  int
+	}{
+		{"minPoolSize", c.MinPoolSize},
+		{"maxPoolSize", c.MaxPoolSize},
+		{"maxPoolWaitTimeMS", c.MaxPoolWaitTimeMS},
+		{"maxPoolIdleTimeMS", c.MaxPoolIdleTimeMS},
+	} {
+		if v.val < 0 {
+			addf("negative %v %d", v.name, v.val)
+		}
+	}
+	if c.MaxPoolSize > 0 && c.MinPoolSize > c.MaxPoolSize {
+		addf("minPoolSize %d greater than maxPoolSize %d", c.MinPoolSize, c.MaxPoolSize)
+	}
+
+	for _, v := range []struct {
+		name string
+		val  time.Duration
+	}{
+		{"connectTimeout", c.ConnectTimeout},
+		{"keepalive", c.Keepalive},
+		{"readTimeout", c.ReadTimeout},
+		{"writeTimeout", c.WriteTimeout},
+		{"slowQueryThreshold", c.SlowQueryThreshold},
+	} {
+		if v.val < 0 {
+			addf("negative %v %v", v.name, v.val)
+		}
+	}
+
+	if r := c.Retry; r != nil {
+		if r.Attempts < 0 {
+			addf("negative retry.attempts %d", r.Attempts)
+		}
+		if r.Backoff < 0 || r.MaxBackoff < 0 {
+			addf("negative retry.backoff or retry.maxBackoff")
+		} else if r.Backoff > 0 && r.MaxBackoff > 0 && r.Backoff > r.MaxBackoff {
+			addf("retry.backoff %v greater than retry.maxBackoff %v", r.Backoff, r.MaxBackoff)
+		}
+		if r.Jitter < 0 || r.Jitter > 1 {
+			addf("retry.jitter %v out of range 0~1", r.Jitter)
+		}
+		for _, e := range r.Errors {
+			if e != RetryNetwork && e != RetryNotMaster {
+				addf("invalid retry.errors %q", e)
+			}
+		}
+	}
+	if b := c.Breaker; b != nil {
+		if b.FailureRate < 0 || b.FailureRate > 1 {
+			addf("breaker.failureRate %v out of range 0~1", b.FailureRate)
+		}
+		if b.MinRequests < 0 || b.HalfOpenProbes < 0 {
+			addf("negative breaker.minRequests or breaker.halfOpenProbes")
+		}
+		if b.Window < 0 || b.OpenDuration < 0 {
+			addf("negative breaker.window or breaker.openDuration")
+		}
+	}
+
+	if o := c.TLS; o != nil {
+		if (o.CertFile == "") != (o.KeyFile == "") {
+			addf("tls.certFile and tls.keyFile must be set together")
+		}
+		for _, f := range []struct{ name, path string }{{"caFile", o.CAFile}, {"certFile", o.CertFile}, {"keyFile", o.KeyFile}} {
+			if f.path != "" {
+				if _, err := os.Stat(f.path); err != nil {
+					addf("tls.%v: %v", f.name, err)
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateFile 检查conf.yml中的所有mongo配置, 不注册不连接, 可用于CI. 问题以"mongo[序号]key: "开头
+func ValidateFile(path string) error {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[interface{}]interface{})
+	if err = yaml.Unmarshal(bs, &values); err != nil {
+		return err
+	}
+	configs, ok := values[CKEY].([]interface{})
+	if !ok && values[CKEY] != nil {
+		return &ConfigError{Problems: []string{"invalid " + CKEY + " section"}}
+	}
+
+	var problems []string
+	keys := make(map[string]bool)
+	for i, config := range configs {
+		prefix := fmt.Sprintf("%v[%v]", CKEY, i)
+		key, option, _, err := getConfig(config)
+		if err != nil {
+			problems = append(problems, prefix+": "+err.Error())
+			continue
+		}
+		prefix += key
+		for _, k := range strings.Split(key, ",") {
+			if k = strings.TrimSpace(k); keys[k] {
+				problems = append(problems, prefix+": duplicate key "+k)
+			}
+			keys[k] = true
+		}
+		if err = mergeOption(option).Validate(); err != nil {
+			for _, p := range err.(*ConfigError).Problems {
+				problems = append(problems, prefix+": "+p)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
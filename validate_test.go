@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	if err := (&Config{Database: "test", Memory: true}).Validate(); err != nil {
+		t.Errorf("memory config: %v", err)
+	}
+	opt := mergeOption(&Config{
+		Mode:         mgo.Mode(9),
+		MinPoolSize:  8,
+		MaxPoolSize:  4,
+		ReadTimeout:  time.Second,
+		WriteTimeout: -time.Second,
+		Retry:        &RetryPolicy{Backoff: time.Second, MaxBackoff: time.Millisecond, Errors: []string{"timeout"}},
+	})
+	opt.Safe.WTimeout = 5000
+	err := opt.Validate()
+	if err == nil {
+		t.Fatal("Validate: expected error")
+	}
+	expect := []string{
+		"missing address",
+		"missing database",
+		"invalid mode 9",
+		"safe.WTimeout 5000ms conflicts with readTimeout 1s: socket times out before write concern",
+		"minPoolSize 8 greater than maxPoolSize 4",
+		"negative writeTimeout -1s",
+		"retry.backoff 1s greater than retry.maxBackoff 1ms",
+		`invalid retry.errors "timeout"`,
+	}
+	if ps := err.(*ConfigError).Problems; !reflect.DeepEqual(ps, expect) {
+		t.Errorf("Validate:\n%q\n%q", ps, expect)
+	}
+	if err = Setup("validate", opt, false); err == nil || Get("validate") != nil {
+		t.Error("Setup with invalid config: expected error")
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mongo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conf.yml")
+	yml := `
+mongo:
+  - key: a
+    address: "127.0.0.1:27017"
+    database: test
+    model: strong
+    connectTimeout: "10 seconds"
+    safe: {"w": 1, "wtimout": 100}
+    maxPoolSize: -1
+  - key: a
+    memory: true
+  - address: "127.0.0.1:27017"
+  - key: b
+    address: "127.0.0.1:27017"
+    database: test
+    mode: primary
+`
+	if err = ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateFile(path)
+	if err == nil {
+		t.Fatal("ValidateFile: expected error")
+	}
+	expect := []string{
+		`mongo[0]a: unknown key "model" (did you mean "mode"?)`,
+		"mongo[0]a: invalid connectTimeout: invalid value to duration: 10 seconds",
+		`mongo[0]a: unknown key "safe.wtimout"`,
+		"mongo[0]a: negative maxPoolSize -1",
+		"mongo[1]a: duplicate key a",
+		"mongo[1]a: missing database",
+		"mongo[2]: missing key",
+	}
+	if ps := err.(*ConfigError).Problems; !reflect.DeepEqual(ps, expect) {
+		t.Errorf("ValidateFile:\n%q\n%q", ps, expect)
+	}
+}